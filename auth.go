@@ -19,8 +19,8 @@ func startAuth() {
 		//log.Println("Got request for:", r.URL.String())
 	})
 	go func() {
-		err := http.ListenAndServe(":8080", nil)
-		checkErr(err)
+		// Runs outside of any command, so there is nothing to return to
+		log.Fatal(http.ListenAndServe(":8080", nil))
 	}()
 
 	url := auth.AuthURL(state)
@@ -49,10 +49,14 @@ func completeAuth(w http.ResponseWriter, r *http.Request) {
 	// use the token to get an authenticated client
 	client := auth.NewClient(tok)
 	_, err = fmt.Fprintln(w, "Login Completed!")
-	checkErr(err)
+	if err != nil {
+		log.Fatal(err)
+	}
 	ch <- &client
 	err = saveToken(tok)
-	checkErr(err)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 // loadToken reads a token from ~/.spotcon/token.gob
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+
+	"github.com/zmb3/spotify"
+)
+
+const configFile = "config.gob"
+
+// config holds the user's settings, stored in ~/.spotcon/config.gob
+type config struct {
+	Aliases       map[string]deviceAlias // Device aliases by alias name
+	DefaultDevice string                 // Alias or name of the device to play on when none are active
+	Volumes       map[spotify.ID]int     // Last volume used on each device
+	MaxVolumes    map[spotify.ID]int     // Highest volume allowed on each device
+	Presets       map[string]int         // Volume presets by name
+	Schedule      []scheduledJob         // Jobs run by spotcon daemon
+}
+
+// deviceAlias is a saved name for a device
+type deviceAlias struct {
+	ID   spotify.ID
+	Name string // Name of the device when the alias was saved
+}
+
+// loadConfig returns the user's saved settings
+func loadConfig() *config {
+	cfg := &config{}
+	err := loadData(configFile, cfg)
+	if err != nil && !os.IsNotExist(err) {
+		checkErr(err)
+	}
+	if cfg.Aliases == nil {
+		cfg.Aliases = map[string]deviceAlias{}
+	}
+	if cfg.Volumes == nil {
+		cfg.Volumes = map[spotify.ID]int{}
+	}
+	if cfg.MaxVolumes == nil {
+		cfg.MaxVolumes = map[spotify.ID]int{}
+	}
+	if cfg.Presets == nil {
+		cfg.Presets = map[string]int{}
+	}
+	return cfg
+}
+
+// saveConfig stores the user's settings
+func saveConfig(cfg *config) {
+	err := saveData(configFile, cfg)
+	checkErr(err)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/zmb3/spotify"
+)
+
+// aliasAction is called with spotcon> devices alias
+// Saves ALIAS as a name for DEVICE, or lists the saved aliases if no
+// arguments are given
+func aliasAction(c *cli.Context) error {
+	ctx := getContext(c)
+	cfg := loadConfig()
+	switch c.NArg() {
+	case 0:
+		displayAliases(cfg)
+	case 2:
+		d := getDevices(ctx)
+		v, err := findDevice(d, c.Args().Get(1), cfg)
+		if err != nil {
+			return err
+		}
+		a := strings.ToLower(c.Args().First())
+		cfg.Aliases[a] = deviceAlias{ID: v.ID, Name: v.Name}
+		saveConfig(cfg)
+		fmt.Printf("%s is now %s\n", a, v.Name)
+	default:
+		return showUsage(c)
+	}
+	return nil
+}
+
+// defaultDeviceAction is called with spotcon> devices default
+// Sets the device to play on when no devices are active, or shows the
+// current default if no arguments are given
+func defaultDeviceAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 1 {
+		return showUsage(c)
+	}
+	cfg := loadConfig()
+	if c.Bool("clear") {
+		cfg.DefaultDevice = ""
+		saveConfig(cfg)
+		return nil
+	}
+	if c.NArg() == 0 {
+		if cfg.DefaultDevice == "" {
+			fmt.Println("No default device set.")
+			return nil
+		}
+		fmt.Println("Default device:", cfg.DefaultDevice)
+		return nil
+	}
+	s := c.Args().First()
+	if _, ok := cfg.Aliases[strings.ToLower(s)]; ok {
+		s = strings.ToLower(s)
+	} else {
+		v, err := findDevice(getDevices(ctx), s, cfg)
+		if err != nil {
+			return err
+		}
+		s = v.Name
+	}
+	cfg.DefaultDevice = s
+	saveConfig(cfg)
+	fmt.Println("Default device:", s)
+	return nil
+}
+
+// moveAction is called with spotcon> devices move
+// Moves the current playback session to DEVICE and keeps it playing
+func moveAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	client := newClient(ctx)
+	v, err := findDevice(getDevices(ctx), c.Args().First(), loadConfig())
+	if err != nil {
+		return fmt.Errorf("could not move playback, %v", err)
+	}
+	if v.Restricted {
+		return fmt.Errorf("%s is restricted and cannot be controlled", v.Name)
+	}
+	cur := getActiveDevice(ctx)
+	if cur != nil && cur.ID == v.ID {
+		fmt.Println("Already playing on", v.Name)
+		return nil
+	}
+	err = client.TransferPlayback(v.ID, true)
+	if e, ok := err.(spotify.Error); ok {
+		switch e.Status {
+		case http.StatusNotFound:
+			return fmt.Errorf("%s is unavailable", v.Name)
+		case http.StatusForbidden:
+			return fmt.Errorf("%s is restricted and cannot be controlled", v.Name)
+		}
+	}
+	checkErr(err)
+	cfg := loadConfig()
+	if cur != nil {
+		cfg.Volumes[cur.ID] = cur.Volume
+		if c.Bool("keep-volume") {
+			cfg.Volumes[v.ID] = cur.Volume
+		}
+	}
+	restoreVolume(ctx, v, cfg)
+	fmt.Println("Device:", v.Name)
+	return nil
+}
+
+// unaliasAction is called with spotcon> devices unalias
+// Removes the device alias ALIAS
+func unaliasAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	cfg := loadConfig()
+	a := strings.ToLower(c.Args().First())
+	if _, ok := cfg.Aliases[a]; !ok {
+		return fmt.Errorf("no device alias named %s", a)
+	}
+	delete(cfg.Aliases, a)
+	if cfg.DefaultDevice == a {
+		cfg.DefaultDevice = ""
+	}
+	saveConfig(cfg)
+	return nil
+}
+
+// displayAliases prints each device alias and the device it names
+func displayAliases(cfg *config) {
+	if len(cfg.Aliases) == 0 {
+		fmt.Println("No device aliases saved.")
+		return
+	}
+	var a []string
+	for k := range cfg.Aliases {
+		a = append(a, k)
+	}
+	sort.Strings(a)
+	fmt.Println("Aliases:")
+	for _, k := range a {
+		fmt.Printf("  %s: %s\n", k, cfg.Aliases[k].Name)
+	}
+}
+
+// findDevice finds a device in d by one of (in order of preference)
+//   - a saved alias
+//   - its number displayed from devicesAction()
+//   - its full name
+//   - part of its name
+func findDevice(d []spotify.PlayerDevice, s string, cfg *config) (*spotify.PlayerDevice, error) {
+	if a, ok := cfg.Aliases[strings.ToLower(s)]; ok {
+		for i, v := range d {
+			if v.ID == a.ID {
+				return &d[i], nil
+			}
+		}
+		// Device IDs can change, so fall back to the saved name
+		for i, v := range d {
+			if v.Name == a.Name {
+				return &d[i], nil
+			}
+		}
+		return nil, fmt.Errorf("%s (%s) is not available", s, a.Name)
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		if i > 0 && i <= len(d) {
+			return &d[i-1], nil
+		}
+		return nil, fmt.Errorf("incorrect device number: %s", s)
+	}
+	for i, v := range d {
+		if strings.EqualFold(v.Name, s) {
+			return &d[i], nil
+		}
+	}
+	for i, v := range d {
+		if strings.Contains(strings.ToLower(v.Name), strings.ToLower(s)) {
+			return &d[i], nil
+		}
+	}
+	return nil, fmt.Errorf("could not find device: %s", s)
+}
+
+// getActiveDevice returns the actively playing device
+// Returns nil if no devices are active
+func getActiveDevice(ctx context.Context) *spotify.PlayerDevice {
+	for _, v := range getDevices(ctx) {
+		if v.Active {
+			return &v
+		}
+	}
+	return nil
+}
+
+// getAliasNames returns the aliases saved for the device with the specified ID
+func getAliasNames(cfg *config, id spotify.ID) []string {
+	var a []string
+	for k, v := range cfg.Aliases {
+		if v.ID == id {
+			a = append(a, k)
+		}
+	}
+	sort.Strings(a)
+	return a
+}
+
+// getDevices returns the user's available devices
+func getDevices(ctx context.Context) []spotify.PlayerDevice {
+	client := newClient(ctx)
+	d, err := client.PlayerDevices()
+	checkErr(err)
+	return d
+}
+
+// setDefaultDevice transfers playback to the default device if no devices
+// are active
+func setDefaultDevice(ctx context.Context) error {
+	cfg := loadConfig()
+	if cfg.DefaultDevice == "" || getActiveDevice(ctx) != nil {
+		return nil
+	}
+	fmt.Println("No devices active, playing on", cfg.DefaultDevice)
+	return setDevice(ctx, cfg.DefaultDevice)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/zmb3/spotify"
+)
+
+// recommendAction is called with spotcon> recommend
+// Displays tracks recommended from the current track, its artists, and any
+// genres specified with --genre. The tracks can be played by number like
+// the results of searchAction()
+func recommendAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 0 {
+		return showUsage(c)
+	}
+	var seeds spotify.Seeds
+	for _, g := range strings.Split(c.String("genre"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			seeds.Genres = append(seeds.Genres, g)
+		}
+	}
+	if len(seeds.Genres) > 5 {
+		return errors.New("can only recommend from up to 5 genres")
+	}
+	item := getPlayingItem(ctx)
+	if item != nil && item.Track != nil && len(seeds.Genres) < 5 {
+		seeds.Tracks = []spotify.ID{item.Track.ID}
+		for _, v := range item.Track.Artists {
+			if len(seeds.Tracks)+len(seeds.Artists)+len(seeds.Genres) == 5 {
+				break
+			}
+			seeds.Artists = append(seeds.Artists, v.ID)
+		}
+	}
+	if len(seeds.Tracks) == 0 && len(seeds.Genres) == 0 {
+		if item != nil {
+			return errors.New("episodes can't seed recommendations, specify a --genre to recommend from")
+		}
+		return errors.New("nothing is playing, specify a --genre to recommend from")
+	}
+	ta, err := getTrackAttributes(c)
+	if err != nil {
+		return err
+	}
+	l := c.Int("limit")
+	if l < 1 || l > 100 {
+		return errors.New("limit must be between 1 and 100")
+	}
+	client := newClient(ctx)
+	r, err := client.GetRecommendations(seeds, ta, &spotify.Options{Limit: &l})
+	checkErr(err)
+	if len(r.Tracks) == 0 {
+		fmt.Println("No recommendations found.")
+		return nil
+	}
+	setSearchResults(&spotify.SearchResult{
+		Tracks: &spotify.FullTrackPage{Tracks: getFullTracks(r.Tracks)},
+	})
+	displayLastSearch()
+	return nil
+}
+
+// recentAction is called with spotcon> recent
+// Displays the user's recently played tracks and when they were played
+// The tracks can be played by number like the results of searchAction()
+func recentAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 0 {
+		return showUsage(c)
+	}
+	l := c.Int("limit")
+	if l < 1 || l > 50 {
+		return errors.New("limit must be between 1 and 50")
+	}
+	client := newClient(ctx)
+	r, err := client.PlayerRecentlyPlayedOpt(&spotify.RecentlyPlayedOptions{Limit: l})
+	checkErr(err)
+	if len(r) == 0 {
+		fmt.Println("No recently played tracks found.")
+		return nil
+	}
+	t := template.New("shortTrackTemplate")
+	t, err = t.Parse(shortTrackTemplate)
+	checkErr(err)
+	var tr []spotify.SimpleTrack
+	fmt.Println("Recently Played:")
+	for i, v := range r {
+		tr = append(tr, v.Track)
+		fmt.Printf("  [%d]:\t%s\t", i+1, getTimeAgo(v.PlayedAt))
+		err = t.Execute(os.Stdout, v.Track)
+		checkErr(err)
+	}
+	setSearchResults(&spotify.SearchResult{
+		Tracks: &spotify.FullTrackPage{Tracks: getFullTracks(tr)},
+	})
+	return nil
+}
+
+// topAction is called with spotcon> top (tracks, artists)
+// Displays the user's top tracks if t is track or top artists if t is artist
+// The items can be played by number like the results of searchAction()
+func topAction(c *cli.Context, t string) error {
+	ctx := getContext(c)
+	if c.NArg() > 0 {
+		return showUsage(c)
+	}
+	tr := c.String("range")
+	switch tr {
+	case "short", "medium", "long":
+	default:
+		return errors.New("range must be one of (short, medium, long)")
+	}
+	l := c.Int("limit")
+	if l < 1 || l > 50 {
+		return errors.New("limit must be between 1 and 50")
+	}
+	o := spotify.Options{Limit: &l, Timerange: &tr}
+	r := &spotify.SearchResult{}
+	client := newClient(ctx)
+	var err error
+	if t == track {
+		r.Tracks, err = client.CurrentUsersTopTracksOpt(&o)
+	} else {
+		r.Artists, err = client.CurrentUsersTopArtistsOpt(&o)
+	}
+	checkErr(err)
+	setSearchResults(r)
+	if len(LastResults) == 0 {
+		fmt.Printf("No top %ss found.\n", t)
+		return nil
+	}
+	displayLastSearch()
+	return nil
+}
+
+// getFullTracks returns a []spotify.FullTrack with each track in t
+// Only the fields of spotify.SimpleTrack are set
+func getFullTracks(t []spotify.SimpleTrack) []spotify.FullTrack {
+	r := make([]spotify.FullTrack, len(t))
+	for i, v := range t {
+		r[i].SimpleTrack = v
+	}
+	return r
+}
+
+// getTimeAgo returns how long ago t was e.g. "15:04 (20m ago)"
+func getTimeAgo(t time.Time) string {
+	d := time.Since(t)
+	a := ""
+	switch {
+	case d < time.Minute:
+		a = "just now"
+	case d < time.Hour:
+		a = fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		a = fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		a = fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format("Jan 2 15:04"), a)
+}
+
+// getTrackAttributes returns the tunable track attributes set by the flags of
+// spotcon> recommend. Each flag is either a target value (0.8) or a
+// range of values (0.6-0.9)
+func getTrackAttributes(c *cli.Context) (*spotify.TrackAttributes, error) {
+	ta := spotify.NewTrackAttributes()
+	attrs := []struct {
+		name             string
+		min, max, target func(float64) *spotify.TrackAttributes
+	}{
+		{"acousticness", ta.MinAcousticness, ta.MaxAcousticness, ta.TargetAcousticness},
+		{"danceability", ta.MinDanceability, ta.MaxDanceability, ta.TargetDanceability},
+		{"energy", ta.MinEnergy, ta.MaxEnergy, ta.TargetEnergy},
+		{"tempo", ta.MinTempo, ta.MaxTempo, ta.TargetTempo},
+		{"valence", ta.MinValence, ta.MaxValence, ta.TargetValence},
+	}
+	for _, a := range attrs {
+		s := c.String(a.name)
+		if s == "" {
+			continue
+		}
+		r := strings.SplitN(s, "-", 2)
+		lo, err := strconv.ParseFloat(r[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", a.name, s)
+		}
+		if len(r) == 1 {
+			a.target(lo)
+			continue
+		}
+		hi, err := strconv.ParseFloat(r[1], 64)
+		if err != nil || hi < lo {
+			return nil, fmt.Errorf("invalid %s: %s", a.name, s)
+		}
+		a.min(lo)
+		a.max(hi)
+	}
+	return ta, nil
+}
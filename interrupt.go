@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// errInterrupted stops the running command when Ctrl-C is pressed
+var errInterrupted = errors.New("interrupted")
+
+// contextKey is the key of the running command's context in app.Metadata
+const contextKey = "context"
+
+// contextTransport sends each request with the context of the client it
+// belongs to, so pressing Ctrl-C only cancels the requests of the command
+// that made them
+type contextTransport struct {
+	Base http.RoundTripper
+	ctx  context.Context
+}
+
+// RoundTrip sends req with the context of the client
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.Base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// getContext returns the context of the command run with c
+// It is cancelled when Ctrl-C is pressed at the spotcon> prompt
+func getContext(c *cli.Context) context.Context {
+	if ctx, ok := c.App.Metadata[contextKey].(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// isCancelled returns true if err is from a request cancelled by Ctrl-C
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// runCommand runs a command line from the spotcon> prompt
+// Pressing Ctrl-C cancels the requests of the command and returns to the
+// prompt instead of quitting
+func runCommand(app *cli.App, line string) (err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if app.Metadata == nil {
+		app.Metadata = map[string]interface{}{}
+	}
+	app.Metadata[contextKey] = ctx
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-done:
+		}
+	}()
+	defer func() {
+		signal.Stop(sig)
+		close(done)
+		cancel()
+		delete(app.Metadata, contextKey)
+		if r := recover(); r != nil {
+			if r != errInterrupted {
+				panic(r)
+			}
+			fmt.Println("\nInterrupted.")
+			err = nil
+		}
+	}()
+	return runLine(app, line)
+}
+
+// sleepCommand waits for d or until ctx is cancelled
+// Returns false if it was cancelled
+func sleepCommand(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/zmb3/spotify"
+)
+
+const markerFile = "markers.gob"
+
+// marker is a named position within a track or episode
+type marker struct {
+	Name     string
+	Position int // Milliseconds from the beginning of the item
+}
+
+// markAddAction is called with spotcon> mark add
+// Saves the current position in playback as NAME
+func markAddAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	u, pr, _ := getMarkerItem(ctx)
+	if u == "" {
+		return errNothingPlaying
+	}
+	n := c.Args().First()
+	m := loadMarkers()
+	ms := m[u][:0]
+	for _, v := range m[u] {
+		if !strings.EqualFold(v.Name, n) {
+			ms = append(ms, v)
+		}
+	}
+	ms = append(ms, marker{Name: n, Position: pr})
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Position < ms[j].Position })
+	m[u] = ms
+	err := saveData(markerFile, m)
+	checkErr(err)
+	fmt.Printf("Marked \"%s\" at [%s]\n", n, getTimestamp(pr))
+	return nil
+}
+
+// markGoAction is called with spotcon> mark go
+// Seeks to the marker NAME in the current track or episode
+func markGoAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	u, _, _ := getMarkerItem(ctx)
+	if u == "" {
+		return errNothingPlaying
+	}
+	for _, v := range loadMarkers()[u] {
+		if strings.EqualFold(v.Name, c.Args().First()) {
+			client := newClient(ctx)
+			err := client.Seek(v.Position)
+			checkErr(err)
+			err = waitForPosition(ctx, v.Position)
+			if err != nil {
+				return err
+			}
+			displayProgress(ctx)
+			return nil
+		}
+	}
+	return fmt.Errorf("no marker named %s", c.Args().First())
+}
+
+// markListAction is called with spotcon> mark list
+// Lists the markers saved for the current track or episode
+func markListAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 0 {
+		return showUsage(c)
+	}
+	u, _, name := getMarkerItem(ctx)
+	if u == "" {
+		return errNothingPlaying
+	}
+	ms := loadMarkers()[u]
+	if len(ms) == 0 {
+		fmt.Println("No markers saved for", name)
+		return nil
+	}
+	fmt.Printf("Markers for %s:\n", name)
+	for i, v := range ms {
+		fmt.Printf("  [%d]:\t[%s] %s\n", i+1, getTimestamp(v.Position), v.Name)
+	}
+	return nil
+}
+
+// markRmAction is called with spotcon> mark rm
+// Removes the marker NAME from the current track or episode
+func markRmAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	u, _, _ := getMarkerItem(ctx)
+	if u == "" {
+		return errNothingPlaying
+	}
+	m := loadMarkers()
+	for i, v := range m[u] {
+		if strings.EqualFold(v.Name, c.Args().First()) {
+			m[u] = append(m[u][:i], m[u][i+1:]...)
+			if len(m[u]) == 0 {
+				delete(m, u)
+			}
+			err := saveData(markerFile, m)
+			checkErr(err)
+			return nil
+		}
+	}
+	return fmt.Errorf("no marker named %s", c.Args().First())
+}
+
+// getMarkerItem returns the URI, progress, and name of the item playing
+// Returns an empty URI if nothing is playing
+func getMarkerItem(ctx context.Context) (spotify.URI, int, string) {
+	p := getPlayingItem(ctx)
+	if p == nil {
+		return "", 0, ""
+	}
+	return p.URI, p.Progress, fmt.Sprintf("\"%s\"", p.Name)
+}
+
+// loadMarkers returns the saved markers of each track or episode by URI
+func loadMarkers() map[spotify.URI][]marker {
+	m := map[spotify.URI][]marker{}
+	err := loadData(markerFile, &m)
+	if err != nil && !os.IsNotExist(err) {
+		checkErr(err)
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/bobappleyard/readline"
+)
+
+// interactive is true while commands are being read from the spotcon> prompt
+// Output is only paged in interactive mode
+var interactive bool
+
+// displayPaged prints s through a pager when running interactively
+//   - $PAGER is used if it is set
+//   - less is used if it is installed
+//   - otherwise the built-in pager is used
+//
+// s is printed directly when not interactive or stdout is not a terminal
+func displayPaged(s string) {
+	if !interactive || !isTerminal(os.Stdout) {
+		fmt.Print(s)
+		return
+	}
+	p := strings.Fields(os.Getenv("PAGER"))
+	if len(p) == 0 {
+		if _, err := exec.LookPath("less"); err == nil {
+			p = []string{"less"}
+		}
+	}
+	if len(p) > 0 {
+		cmd := exec.Command(p[0], p[1:]...)
+		cmd.Stdin = strings.NewReader(s)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err == nil {
+			return
+		}
+		fmt.Printf("ERROR: Could not run pager %q, using built-in pager.\n", p[0])
+	}
+	displayBuiltinPaged(s)
+}
+
+// displayBuiltinPaged prints s one screen at a time
+// Press enter to show the next screen or q to stop
+func displayBuiltinPaged(s string) {
+	lines := strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n")
+	h := getTerminalHeight() - 1
+	for i := 0; i < len(lines); i += h {
+		end := i + h
+		if end > len(lines) {
+			end = len(lines)
+		}
+		fmt.Print(strings.Join(lines[i:end], ""))
+		if end == len(lines) {
+			fmt.Println()
+			return
+		}
+		r, err := readline.String("\n-- More -- (enter: next page, q: quit) ")
+		if err != nil || strings.TrimSpace(r) == "q" {
+			return
+		}
+	}
+}
+
+// getTerminalHeight returns the number of lines in the terminal
+// Uses $LINES if it is set or 24 otherwise
+func getTerminalHeight() int {
+	if h, err := strconv.Atoi(os.Getenv("LINES")); err == nil && h > 1 {
+		return h
+	}
+	return 24
+}
+
+// isTerminal returns true if f is a terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -11,12 +15,58 @@ import (
 	"bytes"
 	"github.com/urfave/cli"
 	"github.com/zmb3/spotify"
-	"os/exec"
 )
 
 // LastSearch is the results of the last search query
 var LastSearch *spotify.SearchResult
 
+// LastLib is every item of the last libAction() listing in the order they are numbered
+var LastLib []interface{}
+
+// LastQuery is the query used for LastSearch
+var LastQuery searchQuery
+
+// LastResults is every item of LastSearch in the order they are numbered
+var LastResults []interface{}
+
+// searchPages is each page of LastSearch in the order they were fetched
+var searchPages []resultPage
+
+// errNothingPlaying is returned by commands that need something playing
+var errNothingPlaying = errors.New("nothing is playing")
+
+// playStart is where and how playback begins within a context
+type playStart struct {
+	At       string // Track number, URI, or name within an album or playlist
+	Position int    // Position within the first track in milliseconds
+	Mode     string // How to play an artist, one of (top, discography, radio)
+}
+
+// resultPage is one page of results from searchAction()
+type resultPage struct {
+	*spotify.SearchResult
+	Shows    []podcastShow
+	Episodes []podcastEpisode
+	Total    int // Largest number of results available for any type
+}
+
+// searchQuery holds the parameters of a search so further pages can be fetched
+type searchQuery struct {
+	Query    string
+	Type     spotify.SearchType
+	Podcasts []string // Podcast types to search for, any of (show, episode)
+	Market   string
+	Limit    int
+	Offset   int // Offset of the next page to fetch
+}
+
+var (
+	// yearRange matches a year (1999) or a range of years (1990-1999)
+	yearRange = regexp.MustCompile(`^\d{4}(-\d{4})?$`)
+	// market matches an ISO 3166-1 alpha-2 country code or from_token
+	market = regexp.MustCompile(`^([A-Za-z]{2}|from_token)$`)
+)
+
 const (
 	track  = "track"
 	album  = "album"
@@ -24,26 +74,67 @@ const (
 	plist  = "playlist"
 )
 
+const (
+	stateTimeout = 3 * time.Second        // How long to wait for a change to show in the player state
+	statePoll    = 250 * time.Millisecond // How often to check the player state while waiting
+)
+
+// appendSearchResult appends each of the result pages in r to those in dst
+func appendSearchResult(dst *spotify.SearchResult, r *spotify.SearchResult) {
+	if r.Tracks != nil {
+		if dst.Tracks == nil {
+			dst.Tracks = r.Tracks
+		} else {
+			dst.Tracks.Tracks = append(dst.Tracks.Tracks, r.Tracks.Tracks...)
+			dst.Tracks.Total = r.Tracks.Total
+		}
+	}
+	if r.Artists != nil {
+		if dst.Artists == nil {
+			dst.Artists = r.Artists
+		} else {
+			dst.Artists.Artists = append(dst.Artists.Artists, r.Artists.Artists...)
+			dst.Artists.Total = r.Artists.Total
+		}
+	}
+	if r.Albums != nil {
+		if dst.Albums == nil {
+			dst.Albums = r.Albums
+		} else {
+			dst.Albums.Albums = append(dst.Albums.Albums, r.Albums.Albums...)
+			dst.Albums.Total = r.Albums.Total
+		}
+	}
+	if r.Playlists != nil {
+		if dst.Playlists == nil {
+			dst.Playlists = r.Playlists
+		} else {
+			dst.Playlists.Playlists = append(dst.Playlists.Playlists, r.Playlists.Playlists...)
+			dst.Playlists.Total = r.Playlists.Total
+		}
+	}
+}
+
 // checkSaved looks for the specified string in the user's saved library
 // t is the type of s and can be any of (album, playlist, track)
 // Returns the URI of s if found or "" if not found
-func checkSaved(s string, t string) spotify.URI {
+func checkSaved(ctx context.Context, s string, t string) spotify.URI {
 	s = strings.ToLower(s)
 	switch t {
 	case track:
-		for _, v := range getSavedTracks() {
+		for _, v := range getSavedTracks(ctx) {
 			if s == strings.ToLower(v.Name) {
 				return v.URI
 			}
 		}
 	case album:
-		for _, v := range getSavedAlbums() {
+		for _, v := range getSavedAlbums(ctx) {
 			if s == strings.ToLower(v.Name) {
 				return v.URI
 			}
 		}
 	case plist:
-		for _, v := range getSavedPlaylists() {
+		for _, v := range getSavedPlaylists(ctx) {
 			if s == strings.ToLower(v.Name) {
 				return v.URI
 			}
@@ -54,309 +145,511 @@ func checkSaved(s string, t string) spotify.URI {
 
 // devicesAction is called with spotcon> devices
 // Lists the user's Spotify Connected devices
-func devicesAction(c *cli.Context) {
+func devicesAction(c *cli.Context) error {
+	ctx := getContext(c)
 	if c.NArg() > 0 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
-	client := auth.NewClient(tok)
-	d, err := client.PlayerDevices()
-	checkErr(err)
+	cfg := loadConfig()
 	fmt.Println("Devices:")
-	for i, v := range d {
+	for i, v := range getDevices(ctx) {
 		fmt.Printf("  [%d]: %v (%v)", i+1, v.Name, v.Type)
+		if a := getAliasNames(cfg, v.ID); len(a) > 0 {
+			fmt.Printf(" [%s]", strings.Join(a, ", "))
+		}
 		if v.Active {
 			fmt.Println(" ACTIVE")
 		} else {
 			fmt.Println()
 		}
 	}
+	return nil
 }
 
 // TODO: Use Offset to retrieve all of the user's saved library
-// libAction is called with spotcon> lib
-// Prints the user's saved library (tracks, albums, playlists) to $PAGER
-func libAction(c *cli.Context) {
+// libAction is called with spotcon> lib [tracks, albums, playlists]
+// Prints the user's saved library to $PAGER, or only the saved items of type t
+// if t is not empty. The numbered items are stored in LastLib
+func libAction(c *cli.Context, t string) error {
+	ctx := getContext(c)
+	if c.NArg() > 0 {
+		return showUsage(c)
+	}
 	var b bytes.Buffer
-	cmd := exec.Command("/usr/bin/less")
+	LastLib = nil
 	// Tracks
-	t := getSavedTracks()
-	tt := template.New("shortTrackTemplate")
-	tt, err := tt.Parse(shortTrackTemplate)
-	checkErr(err)
-	b.WriteString("Tracks:\n")
-	for i, v := range t {
-		b.WriteString(fmt.Sprintf("  [%d]:\t", i+1))
-		err := tt.Execute(&b, v)
+	if t == "" || t == track {
+		tt := template.New("shortTrackTemplate")
+		tt, err := tt.Parse(shortTrackTemplate)
 		checkErr(err)
+		b.WriteString("Tracks:\n")
+		for _, v := range getSavedTracks(ctx) {
+			LastLib = append(LastLib, v.FullTrack)
+			b.WriteString(fmt.Sprintf("  [%d]:\t", len(LastLib)))
+			err := tt.Execute(&b, v)
+			checkErr(err)
+		}
 	}
 	// Albums
-	al := getSavedAlbums()
-	at := template.New("shortAlbumTemplate")
-	at, err = at.Parse(shortAlbumTemplate)
-	b.WriteString("Albums:\n")
-	for i, v := range al {
-		b.WriteString(fmt.Sprintf("  [%d]:\t", i+1))
-		err := at.Execute(&b, v)
+	if t == "" || t == album {
+		at := template.New("shortAlbumTemplate")
+		at, err := at.Parse(shortAlbumTemplate)
 		checkErr(err)
+		b.WriteString("Albums:\n")
+		for _, v := range getSavedAlbums(ctx) {
+			LastLib = append(LastLib, v.SimpleAlbum)
+			b.WriteString(fmt.Sprintf("  [%d]:\t", len(LastLib)))
+			err := at.Execute(&b, v)
+			checkErr(err)
+		}
 	}
 	// Playlists
-	p := getSavedPlaylists()
-	b.WriteString("Playlists:\n")
-	for i, v := range p {
-		b.WriteString(fmt.Sprintf("  [%d]:\t%s - \"%s\"\n", i+1, v.Name, v.Owner.ID))
+	if t == "" || t == plist {
+		b.WriteString("Playlists:\n")
+		for _, v := range getSavedPlaylists(ctx) {
+			LastLib = append(LastLib, v)
+			b.WriteString(fmt.Sprintf("  [%d]:\t%s - \"%s\"\n", len(LastLib), v.Name, v.Owner.ID))
+		}
 	}
-	cmd.Stdin = strings.NewReader(b.String())
-	cmd.Stdout = os.Stdout
-	err = cmd.Run()
-	checkErr(err)
+	// Shows
+	if t == "" || t == show {
+		b.WriteString("Shows:\n")
+		for _, v := range getSavedShows(ctx) {
+			LastLib = append(LastLib, v)
+			b.WriteString(fmt.Sprintf("  [%d]:\t\"%s\" by %s\n", len(LastLib), v.Name, v.Publisher))
+		}
+	}
+	displayPaged(b.String())
+	return nil
 }
 
 // luckySearch searches Spotify for specified string
 // t is the type of s and can be any of (artist, album, playlist, track)
 // Returns the first result matching the string specified
-func luckySearch(s string, t string) spotify.URI {
-	client := auth.NewClient(tok)
+func luckySearch(ctx context.Context, s string, t string) (spotify.URI, error) {
+	client := newClient(ctx)
 	switch t {
 	case track:
 		r, err := client.Search(s, spotify.SearchType(8))
 		checkErr(err)
 		tr := r.Tracks.Tracks
 		if len(tr) == 0 {
-			fmt.Printf("No %ss found matching: %s", t, s)
-			return ""
+			return "", fmt.Errorf("no %ss found matching: %s", t, s)
 		}
-		return tr[0].URI
+		return tr[0].URI, nil
 	case artist:
 		r, err := client.Search(s, spotify.SearchType(2))
 		checkErr(err)
 		ar := r.Artists.Artists
 		if len(ar) == 0 {
-			fmt.Printf("No %ss found matching: %s", t, s)
-			return ""
+			return "", fmt.Errorf("no %ss found matching: %s", t, s)
 		}
-		return ar[0].URI
+		return ar[0].URI, nil
 	case album:
 		r, err := client.Search(s, spotify.SearchType(1))
 		checkErr(err)
 		al := r.Albums.Albums
 		if len(al) == 0 {
-			fmt.Printf("No %ss found matching: %s", t, s)
-			return ""
+			return "", fmt.Errorf("no %ss found matching: %s", t, s)
 		}
-		return al[0].URI
+		return al[0].URI, nil
 	case plist:
 		r, err := client.Search(s, spotify.SearchType(4))
 		checkErr(err)
 		pl := r.Playlists.Playlists
 		if len(pl) == 0 {
-			fmt.Printf("No %ss found matching: %s", t, s)
-			return ""
+			return "", fmt.Errorf("no %ss found matching: %s", t, s)
 		}
-		return pl[0].URI
+		return pl[0].URI, nil
 	default:
-		return ""
+		return "", nil
 	}
 }
 
 // nowAction is called with spotcon> now
 // Displays information about Now Playing
-func nowAction(c *cli.Context) {
+func nowAction(c *cli.Context) error {
+	ctx := getContext(c)
 	if c.NArg() > 0 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
-	tr := getCurrentTrack()
-	t := template.New("longTrackTemplate")
-	t, err := t.Parse(longTrackTemplate)
-	checkErr(err)
-
-	fmt.Println("Device:", getActiveDeviceName())
-	err = t.Execute(os.Stdout, tr)
-	checkErr(err)
-	displayVolume()
-	displayProgress()
+	if c.Bool("watch") {
+		watchNow(ctx, c.Duration("interval"))
+		return nil
+	}
+	displayNow(ctx)
+	return nil
 }
 
 // optAction is called with spotcon> opt
 // Used to set options: (repeat, shuffle) to (on, off)
-func optAction(c *cli.Context) {
+func optAction(c *cli.Context) error {
+	ctx := getContext(c)
 	if c.NArg() > 0 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
 	if c.String("repeat") != "" {
-		switch c.String("repeat") {
-		case "on":
-			setRepeat("context")
-		case "off":
-			setRepeat("off")
-		default:
-			err := cli.ShowCommandHelp(c, c.Command.Name)
-			checkErr(err)
-			return
+		r, err := parseRepeat(c.String("repeat"))
+		if err != nil {
+			fmt.Println("ERROR:", err)
+			return showUsage(c)
+		}
+		setRepeat(ctx, r)
+		err = waitForState(ctx, "repeat", func(s *spotify.PlayerState) bool { return s.RepeatState == r })
+		if err != nil {
+			return err
 		}
 	}
 	if c.String("shuffle") != "" {
+		var b bool
 		switch c.String("shuffle") {
 		case "on":
-			setShuffle(true)
+			b = true
 		case "off":
-			setShuffle(false)
+			b = false
+		default:
+			return showUsage(c)
+		}
+		setShuffle(ctx, b)
+		err := waitForState(ctx, "shuffle", func(s *spotify.PlayerState) bool { return s.ShuffleState == b })
+		if err != nil {
+			return err
+		}
+	}
+	displayOpts(ctx)
+	return nil
+}
+
+// optRepeatAction is called with spotcon> opt repeat
+// Sets repeat to one of (track, context, off), or moves to the next of
+// off, context, and track if no value is given
+func optRepeatAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 1 {
+		return showUsage(c)
+	}
+	var r string
+	if c.NArg() == 1 {
+		var err error
+		r, err = parseRepeat(c.Args().First())
+		if err != nil {
+			return err
 		}
+	} else {
+		client := newClient(ctx)
+		state, err := client.PlayerState()
+		checkErr(err)
+		r = getNextRepeat(state.RepeatState)
+	}
+	setRepeat(ctx, r)
+	err := waitForState(ctx, "repeat", func(s *spotify.PlayerState) bool { return s.RepeatState == r })
+	if err != nil {
+		return err
 	}
-	time.Sleep(200 * time.Millisecond)
-	displayOpts()
+	displayOpts(ctx)
+	return nil
 }
 
 // pauseAction is called with spotcon> pause
 // Pauses the current playback
-func pauseAction(c *cli.Context) {
+func pauseAction(c *cli.Context) error {
+	ctx := getContext(c)
 	if c.NArg() > 0 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
-	client := auth.NewClient(tok)
+	client := newClient(ctx)
 	err := client.Pause()
 	checkErr(err)
+	return nil
 }
 
 // play begins playback
 //      - t determines the type which is one of (artist, album, playlist, track)
-//      - if s is a number, playNum() is called to handle playback
+//      - if s is a number, playNum() is called to play that item from r
+//      - if s lists numbers within r (1-3,5), those tracks are played in order
 //      - if s is a string, the user's saved tracks are searched for matches and
 //        no matches are found, the first result from a search is played
-func play(s string, t string) {
+//      - playback begins at st
+func play(ctx context.Context, s string, t string, r []interface{}, st playStart) error {
 	var u spotify.URI
-	client := auth.NewClient(tok)
-	if i, err := strconv.Atoi(s); err == nil {
-		switch t {
-		case track:
-			t := getInterfaceSlice(LastSearch.Tracks.Tracks)
-			playNum(i, t)
-		case artist:
-			t := getInterfaceSlice(LastSearch.Artists.Artists)
-			playNum(i, t)
-		case album:
-			t := getInterfaceSlice(LastSearch.Albums.Albums)
-			playNum(i, t)
-		case plist:
-			t := getInterfaceSlice(LastSearch.Playlists.Playlists)
-			playNum(i, t)
+	if n, err := parseNumbers(s, len(r)); err == nil && len(n) > 1 {
+		if t != track {
+			return errors.New("can only play several tracks at once")
 		}
-		return
+		return playNums(ctx, n, r, st)
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return playNum(ctx, i, t, r, st)
 	}
-	if a := checkSaved(s, t); a != "" {
+	if a := checkSaved(ctx, s, t); a != "" {
 		u = a
 	} else {
-		u = luckySearch(s, t)
-	}
-	if t == track && u != "" {
-		o := spotify.PlayOptions{URIs: []spotify.URI{u}}
-		err := client.PlayOpt(&o)
-		checkErr(err)
-		return
+		var err error
+		u, err = luckySearch(ctx, s, t)
+		if err != nil {
+			return err
+		}
 	}
-	if u != "" {
-		o := spotify.PlayOptions{PlaybackContext: &u}
-		err := client.PlayOpt(&o)
-		checkErr(err)
-		return
+	if u == "" {
+		return nil
 	}
+	return playURI(ctx, u, t, st)
 }
 
-// TODO: Add ability to play (albums, playlists, tracks) from libAction()
 // playAction is called with spotcon> play
 // Start/Resumes playback and handles flags
-func playAction(c *cli.Context) {
-	if c.NArg() > 0 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+func playAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 1 {
+		return showUsage(c)
 	}
-	if c.NumFlags() > 2 {
-		if !c.IsSet("device") {
-			fmt.Println("ERROR: Too many flags set.")
-			err := cli.ShowCommandHelp(c, c.Command.Name)
-			checkErr(err)
-			return
-		}
-		if c.NumFlags() > 4 {
-			fmt.Println("ERROR: Too many flags set.")
-			err := cli.ShowCommandHelp(c, c.Command.Name)
-			checkErr(err)
-			return
+	n := c.NArg()
+	for _, f := range []string{track, album, artist, "plist", "tracks-from", "all-results"} {
+		if c.IsSet(f) {
+			n++
 		}
 	}
-	client := auth.NewClient(tok)
+	if n > 1 {
+		fmt.Println("ERROR: Too many flags set.")
+		return showUsage(c)
+	}
+	st, err := getPlayStart(c)
+	if err != nil {
+		return err
+	}
+	var r []interface{}
+	switch c.String("from") {
+	case "search":
+		r = LastResults
+	case "lib":
+		r = LastLib
+	default:
+		return fmt.Errorf("can only play numbers from (search, lib), not %s", c.String("from"))
+	}
+	client := newClient(ctx)
 	if c.IsSet("device") {
-		e := setDevice(c.String("device"))
-		if !e {
-			err := cli.ShowCommandHelp(c, c.Command.Name)
-			checkErr(err)
-			return
+		err := setDevice(ctx, c.String("device"))
+		if err != nil {
+			return err
 		}
-		if c.NumFlags() == 2 { // Device is the only flag set.
-			err := client.Play()
-			checkErr(err)
-			return
+	} else if err := setDefaultDevice(ctx); err != nil {
+		return err
+	}
+	if c.NArg() == 1 {
+		s := c.Args().First()
+		if isLink(s) {
+			return playLink(ctx, s, st)
 		}
+		if strings.HasPrefix(s, "lib:") {
+			s = strings.TrimPrefix(s, "lib:")
+			r = LastLib
+		}
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("not a Spotify link or number from search or lib results, %s", c.Args().First())
+		}
+		return playNum(ctx, i, "", r, st)
 	}
 	if c.IsSet(track) {
-		play(c.String("track"), track)
-		return
+		return play(ctx, c.String("track"), track, r, st)
 	}
 	if c.IsSet(album) {
-		play(c.String("album"), album)
-		return
+		return play(ctx, c.String("album"), album, r, st)
 	}
 	if c.IsSet(artist) {
-		play(c.String("artist"), artist)
-		return
+		return play(ctx, c.String("artist"), artist, r, st)
 	}
-	if c.IsSet(plist) {
-		play(c.String("plist"), plist)
+	if c.IsSet("plist") {
+		return play(ctx, c.String("plist"), plist, r, st)
+	}
+	if c.IsSet("tracks-from") {
+		u, err := readURIs(c.String("tracks-from"))
+		if err != nil {
+			return err
+		}
+		return playURIs(ctx, u, st)
+	}
+	if c.Bool("all-results") {
+		var u []spotify.URI
+		for _, v := range r {
+			if v, ok := v.(spotify.FullTrack); ok {
+				u = append(u, v.URI)
+			}
+		}
+		return playURIs(ctx, u, st)
 	}
-	err := client.Play()
+	if st.Mode != "" {
+		return errors.New("choose an artist to play with --mode")
+	}
+	if st.At != "" || st.Position > 0 {
+		return playCurrent(ctx, st)
+	}
+	err = client.Play()
 	checkErr(err)
+	return nil
 }
 
-// playNum plays an item from LastSearch by referencing its number
-// found with searchAction()
-func playNum(i int, t []interface{}) {
-	client := auth.NewClient(tok)
-	if len(t) == 0 {
-		fmt.Println("No search results found.")
-		return
-	}
-	if i > 0 && i < len(t) {
-		u := getURI(t[i-1])
-		if _, ok := t[0].(spotify.FullTrack); ok {
-			o := spotify.PlayOptions{URIs: []spotify.URI{u}}
-			err := client.PlayOpt(&o)
-			checkErr(err)
-			return
+// playArtist plays the artist with the specified ID as a list of tracks
+//      - top plays the artist's top tracks
+//      - discography plays every album by the artist in release order
+//      - radio plays tracks recommended from the artist
+func playArtist(ctx context.Context, id spotify.ID, st playStart) error {
+	var u []spotify.URI
+	client := newClient(ctx)
+	switch st.Mode {
+	case "top":
+		usr, err := client.CurrentUser()
+		checkErr(err)
+		t, err := client.GetArtistsTopTracks(id, usr.Country)
+		checkErr(err)
+		for _, v := range t {
+			u = append(u, v.URI)
 		}
-		o := spotify.PlayOptions{PlaybackContext: &u}
-		err := client.PlayOpt(&o)
+	case "discography":
+		for _, v := range getArtistAlbums(ctx, id) {
+			for _, v := range getAlbumTracks(ctx, v.ID) {
+				u = append(u, v.URI)
+			}
+		}
+	case "radio":
+		l := 50
+		r, err := client.GetRecommendations(spotify.Seeds{Artists: []spotify.ID{id}}, nil, &spotify.Options{Limit: &l})
 		checkErr(err)
-		return
+		for _, v := range r.Tracks {
+			u = append(u, v.URI)
+		}
+	}
+	return playURIs(ctx, u, st)
+}
+
+// playCurrent restarts the current context at st
+// If st does not name a track, the current track is restarted at st.Position
+func playCurrent(ctx context.Context, st playStart) error {
+	p := getPlayingItem(ctx)
+	if p == nil {
+		return errNothingPlaying
+	}
+	if p.Context.URI == "" {
+		if st.At != "" {
+			return errors.New("not playing an album or playlist")
+		}
+		t := track
+		if p.Episode != nil {
+			t = episode
+		}
+		return playURI(ctx, p.URI, t, st)
+	}
+	if st.At == "" {
+		st.At = string(p.URI)
+	}
+	return playURI(ctx, p.Context.URI, p.Context.Type, st)
+}
+
+// playURIs plays a list of tracks or episodes in order
+// st.At may only be the number of the item to begin with
+func playURIs(ctx context.Context, u []spotify.URI, st playStart) error {
+	if len(u) == 0 {
+		return errors.New("no tracks to play")
+	}
+	o := spotify.PlayOptions{URIs: u, PositionMs: st.Position}
+	if st.At != "" {
+		i, err := strconv.Atoi(st.At)
+		if err != nil || i < 1 || i > len(u) {
+			return fmt.Errorf("can only start at a number from 1 to %d, not %s", len(u), st.At)
+		}
+		o.PlaybackOffset = &spotify.PlaybackOffset{Position: i - 1}
+	}
+	client := newClient(ctx)
+	err := client.PlayOpt(&o)
+	checkErr(err)
+	return nil
+}
+
+// playNum plays an item from r by referencing its number
+// found with searchAction() or libAction()
+// If t is not empty the item must be of type t
+func playNum(ctx context.Context, i int, t string, r []interface{}, st playStart) error {
+	v, err := getResult(r, i, t)
+	if err != nil {
+		return err
 	}
+	return playURI(ctx, getURI(v), getResultType(v), st)
+}
+
+// playNums plays the tracks numbered n from r in order
+func playNums(ctx context.Context, n []int, r []interface{}, st playStart) error {
+	var u []spotify.URI
+	for _, i := range n {
+		v, err := getResult(r, i, track)
+		if err != nil {
+			return err
+		}
+		u = append(u, getURI(v))
+	}
+	return playURIs(ctx, u, st)
+}
+
+// playURI begins playback of u which is of type t
+// Tracks and episodes are played on their own, other types are played as a
+// context beginning at st
+func playURI(ctx context.Context, u spotify.URI, t string, st playStart) error {
+	if st.Mode != "" {
+		if t != artist {
+			return fmt.Errorf("can only choose a mode for artists, not a %s", t)
+		}
+		return playArtist(ctx, getID(u), st)
+	}
+	client := newClient(ctx)
+	o := spotify.PlayOptions{PositionMs: st.Position}
+	switch t {
+	case track, episode:
+		if st.At != "" {
+			return fmt.Errorf("cannot start a %s at a track", t)
+		}
+		o.URIs = []spotify.URI{u}
+		if t == episode && st.Position == 0 {
+			e := getEpisode(ctx, getID(u))
+			if p := e.ResumePoint.Position; p > 0 && !e.ResumePoint.FullyPlayed {
+				o.PositionMs = p
+				fmt.Printf("Resuming at [%s]\n", getTimestamp(p))
+			}
+		}
+	default:
+		o.PlaybackContext = &u
+		if st.At != "" {
+			off, err := getPlaybackOffset(ctx, u, t, st.At)
+			if err != nil {
+				return err
+			}
+			o.PlaybackOffset = off
+		}
+	}
+	err := client.PlayOpt(&o)
+	checkErr(err)
+	return nil
 }
 
 // searchAction is called with spotcon> search
 // Preforms a Spotify search with the specified flags
-func searchAction(c *cli.Context) {
+// "more" and "page NUMBER" show further pages of the last search instead
+func searchAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if n := getSearchPageArg(c); n > 0 {
+		if LastSearch == nil {
+			fmt.Println("No previous search results found.")
+			return nil
+		}
+		return searchPage(ctx, n)
+	}
 	var t int
-	client := auth.NewClient(tok)
-	q := strings.Join(c.Args(), " ")
+	q, err := getSearchQuery(c)
+	if err != nil {
+		fmt.Println("ERROR:", err)
+		return showUsage(c)
+	}
 	if q == "" {
 		displayLastSearch()
-		return
+		return nil
 	}
 	if c.Bool(album) {
 		t++
@@ -370,33 +663,99 @@ func searchAction(c *cli.Context) {
 	if c.Bool(track) {
 		t += 8
 	}
-	if t == 0 {
+	var pt []string
+	if c.Bool(show) {
+		pt = append(pt, show)
+	}
+	if c.Bool(episode) {
+		pt = append(pt, episode)
+	}
+	if t == 0 && len(pt) == 0 {
 		t = 15
 	}
-	st := spotify.SearchType(t)
-	var err error
-	LastSearch, err = client.Search(q, st)
-	checkErr(err)
-	displaySearchResults(LastSearch)
+	l := c.Int("limit")
+	if l < 1 || l > 50 {
+		return errors.New("limit must be between 1 and 50")
+	}
+	m := c.String("market")
+	if len(m) == 2 {
+		m = strings.ToUpper(m) // Country codes are upper case, from_token is not
+	}
+	LastQuery = searchQuery{
+		Query:    q,
+		Type:     spotify.SearchType(t),
+		Podcasts: pt,
+		Market:   m,
+		Limit:    l,
+	}
+	LastSearch = &spotify.SearchResult{}
+	LastResults = nil
+	searchPages = nil
+	return searchPage(ctx, 1)
+}
+
+// searchPage displays page n of LastSearch
+// Pages up to and including n are fetched with LastQuery and appended to
+// LastSearch and LastResults so results keep the same number on every page
+func searchPage(ctx context.Context, n int) error {
+	client := newClient(ctx)
+	for len(searchPages) < n {
+		if len(searchPages) > 0 && (LastQuery.Query == "" || LastQuery.Offset >= searchPages[len(searchPages)-1].Total) {
+			break
+		}
+		p := resultPage{SearchResult: &spotify.SearchResult{}}
+		if LastQuery.Type != 0 {
+			o := spotify.Options{Limit: &LastQuery.Limit, Offset: &LastQuery.Offset}
+			if LastQuery.Market != "" {
+				o.Country = &LastQuery.Market
+			}
+			r, err := client.SearchOpt(LastQuery.Query, LastQuery.Type, &o)
+			checkErr(err)
+			p.SearchResult = r
+			p.Total = getSearchTotal(r)
+		}
+		if len(LastQuery.Podcasts) > 0 {
+			var t int
+			p.Shows, p.Episodes, t = searchPodcasts(ctx, LastQuery.Query, LastQuery.Podcasts, LastQuery.Market, LastQuery.Limit, LastQuery.Offset)
+			if t > p.Total {
+				p.Total = t
+			}
+		}
+		appendSearchResult(LastSearch, p.SearchResult)
+		searchPages = append(searchPages, p)
+		LastResults = append(LastResults, getResultSlice(p)...)
+		LastQuery.Offset += LastQuery.Limit
+	}
+	if len(LastResults) == 0 {
+		fmt.Println("No search results found.")
+		return nil
+	}
+	if n > len(searchPages) || len(getResultSlice(searchPages[n-1])) == 0 {
+		fmt.Println("No more search results found.")
+		return nil
+	}
+	displaySearchResults(searchPages[n-1], getPageStart(n))
+	return nil
 }
 
 // seekAction is called with spotcon> seek
 // Seeks forwards if b is true and backwards if b is false
-func seekAction(c *cli.Context, b bool) {
+func seekAction(c *cli.Context, b bool) error {
+	ctx := getContext(c)
 	var err error
 	t := 15 * 1000
 	_ = t
 	if c.NumFlags() > 2 {
 		fmt.Println("ERROR: Cannot seek forward and backwards")
-		err = cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
+	}
+	client := newClient(ctx)
+	p := getPlayingItem(ctx)
+	if p == nil {
+		return errNothingPlaying
 	}
-	client := auth.NewClient(tok)
-	p, err := client.PlayerCurrentlyPlaying()
-	checkErr(err)
 	pr := p.Progress
-	d := p.Item.Duration
+	d := p.Duration
 	if c.Args().First() != "" {
 		t, err = strconv.Atoi(c.Args().First())
 		checkErr(err)
@@ -406,110 +765,163 @@ func seekAction(c *cli.Context, b bool) {
 		if pr+t > d {
 			t = d - pr
 		}
-		err = client.Seek(pr + t)
-		checkErr(err)
+		pr += t
 	} else {
 		if pr-t < 0 {
 			t = pr
 		}
-		err = client.Seek(pr - t)
-		checkErr(err)
+		pr -= t
 	}
-	time.Sleep(150 * time.Millisecond)
-	displayProgress()
+	err = client.Seek(pr)
+	checkErr(err)
+	err = waitForPosition(ctx, pr)
+	if err != nil {
+		return err
+	}
+	displayProgress(ctx)
+	return nil
+}
+
+// seekToAction is called with spotcon> seek to
+// Seeks to a position (m:ss, h:mm:ss) or percent (50%) of the current track
+// If restart is true, seeks to the beginning of the current track
+func seekToAction(c *cli.Context, restart bool) error {
+	ctx := getContext(c)
+	if (restart && c.NArg() != 0) || (!restart && c.NArg() != 1) {
+		return showUsage(c)
+	}
+	client := newClient(ctx)
+	p := getPlayingItem(ctx)
+	if p == nil {
+		return errNothingPlaying
+	}
+	d := p.Duration
+	t := 0
+	var err error
+	if !restart {
+		t, err = parseSeekPosition(c.Args().First(), d)
+		if err != nil {
+			return err
+		}
+	}
+	if t >= d {
+		return fmt.Errorf("position is past the end of the track [%s]", getTimestamp(d))
+	}
+	err = client.Seek(t)
+	checkErr(err)
+	err = waitForPosition(ctx, t)
+	if err != nil {
+		return err
+	}
+	displayProgress(ctx)
+	return nil
 }
 
 // skipAction is called with either spotcon> next or spotcon> prev
 // Playback skips forward if b is true or backwards if b is false
-func skipAction(c *cli.Context, b bool) {
+func skipAction(c *cli.Context, b bool) error {
+	ctx := getContext(c)
 	if c.NArg() > 0 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
-	client := auth.NewClient(tok)
+	client := newClient(ctx)
+	old, err := client.PlayerState()
+	checkErr(err)
 	if b {
-		err := client.Next()
+		err = client.Next()
 		checkErr(err)
 	} else {
-		err := client.Previous()
+		err = client.Previous()
 		checkErr(err)
 	}
-	err := client.Play()
+	err = client.Play()
 	checkErr(err)
-	time.Sleep(200 * time.Millisecond)
-	nowAction(c)
-	return
+	// Previous restarts the current track if it has been playing for a
+	// while, so a new track or a reset position both count as skipped
+	err = waitForState(ctx, "track", func(s *spotify.PlayerState) bool {
+		return getURI(s.Item) != getURI(old.Item) || s.Progress < old.Progress
+	})
+	if err != nil {
+		return err
+	}
+	displayNow(ctx)
+	return nil
 }
 
 // volAdjustAction is called by spotcon> vol (up/down)
 // Increments volume by 10% if percent is not specified
-func volAdjustAction(c *cli.Context, b bool) {
+func volAdjustAction(c *cli.Context, b bool) error {
+	ctx := getContext(c)
 	p := 10
 	if c.Args().First() != "" {
 		var err error
 		p, err = strconv.Atoi(c.Args().First())
 		checkErr(err)
 	}
-	v := getVolume()
+	v := getVolume(ctx)
 	switch b {
 	case true:
 		if v+p >= 100 {
-			setVolume(100)
+			v = setVolume(ctx, 100)
 			break
 		}
-		setVolume(v + p)
+		v = setVolume(ctx, v + p)
 	case false:
 		if v-p <= 0 {
-			setVolume(0)
+			v = setVolume(ctx, 0)
 			break
 		}
-		setVolume(v - p)
+		v = setVolume(ctx, v - p)
+	}
+	err := waitForVolume(ctx, v)
+	if err != nil {
+		return err
 	}
-	time.Sleep(150 * time.Millisecond)
-	displayVolume()
+	displayVolume(ctx)
+	return nil
 }
 
 // volSetAction is called with spotcon> vol set
 // Sets volume to a specified percent
-func volSetAction(c *cli.Context) {
+func volSetAction(c *cli.Context) error {
+	ctx := getContext(c)
 	if c.NArg() != 1 {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
 	i, err := strconv.Atoi(c.Args().First())
 	checkErr(err)
 	if i > 100 {
 		i = 100
 	}
-	setVolume(i)
-	time.Sleep(150 * time.Millisecond)
-	displayVolume()
+	i = setVolume(ctx, i)
+	err = waitForVolume(ctx, i)
+	if err != nil {
+		return err
+	}
+	displayVolume(ctx)
+	return nil
 }
 
 // displayFullTracks prints a shortTrackTemplate of each of the tracks in
-// a []spotify.FullTrack
-func displayFullTracks(r []spotify.FullTrack) {
+// a []spotify.FullTrack, numbered from n
+func displayFullTracks(r []spotify.FullTrack, n int) {
 	fmt.Println("Tracks: ")
 	t := template.New("shortTrackTemplate")
 	t, err := t.Parse(shortTrackTemplate)
 	checkErr(err)
-	for i := 0; i < 5 && i < len(r); i++ {
-		v := r[i]
-		fmt.Printf("  [%d]:\t", i+1)
+	for i, v := range r {
+		fmt.Printf("  [%d]:\t", n+i)
 		err = t.Execute(os.Stdout, v)
 		checkErr(err)
 	}
 }
 
 // func displayFullArtists prints the name of each artist
-// in a []spotify.FullTrack
-func displayFullArtists(r []spotify.FullArtist) {
+// in a []spotify.FullTrack, numbered from n
+func displayFullArtists(r []spotify.FullArtist, n int) {
 	fmt.Println("Artists: ")
-	for i := 0; i < 5 && i < len(r); i++ {
-		v := r[i]
-		fmt.Printf("  [%d]:\t%v\n", i+1, v.Name)
+	for i, v := range r {
+		fmt.Printf("  [%d]:\t%v\n", n+i, v.Name)
 	}
 }
 
@@ -519,13 +931,39 @@ func displayLastSearch() {
 		fmt.Println("No previous search results found.")
 		return
 	}
-	displaySearchResults(LastSearch)
+	n := 1
+	for _, p := range searchPages {
+		displaySearchResults(p, n)
+		n += len(getResultSlice(p))
+	}
+}
+
+// displayNow prints the track or episode that is playing and a status line
+func displayNow(ctx context.Context) {
+	i := getPlayingItem(ctx)
+	if i == nil {
+		fmt.Println("Nothing is playing.")
+		return
+	}
+	t := template.New("longTrackTemplate")
+	t, err := t.Parse(longTrackTemplate)
+	checkErr(err)
+	var v interface{} = i.Track
+	if i.Episode != nil {
+		t, err = template.New("episodeTemplate").Parse(episodeTemplate)
+		checkErr(err)
+		v = i.Episode
+	}
+
+	err = t.Execute(os.Stdout, v)
+	checkErr(err)
+	displayStatus(ctx, i)
 }
 
 // displayOpts prints the current values of shuffle and repeat
 // using the optionsTemplate
-func displayOpts() {
-	client := auth.NewClient(tok)
+func displayOpts(ctx context.Context) {
+	client := newClient(ctx)
 	state, err := client.PlayerState()
 	checkErr(err)
 	t := template.New("optionsTemplate")
@@ -535,90 +973,144 @@ func displayOpts() {
 	checkErr(err)
 }
 
-// displayProgress prints the current playback progress
-func displayProgress() {
-	client := auth.NewClient(tok)
-	p, err := client.PlayerCurrentlyPlaying()
+// displayStatus prints a single line with the playback progress of i,
+// device, volume, shuffle and repeat
+func displayStatus(ctx context.Context, i *playingItem) {
+	client := newClient(ctx)
+	state, err := client.PlayerState()
 	checkErr(err)
-	pr := p.Progress / 1000
-	t := p.Item.Duration / 1000
-	fmt.Printf("[%d:%02d/%d:%02d]\n", pr/60, pr%60, t/60, t%60)
+	p := "Paused"
+	if state.Playing {
+		p = "Playing"
+	}
+	s := "off"
+	if state.ShuffleState {
+		s = "on"
+	}
+	fmt.Printf("%s [%s/%s] on %s | Volume: %d%% | Shuffle: %s | Repeat: %s\n", p,
+		getTimestamp(state.Progress), getTimestamp(i.Duration), state.Device.Name,
+		state.Device.Volume, s, state.RepeatState)
+}
+
+// displayProgress prints the current playback progress
+func displayProgress(ctx context.Context) {
+	p := getPlayingItem(ctx)
+	if p == nil {
+		return
+	}
+	fmt.Printf("[%s/%s]\n", getTimestamp(p.Progress), getTimestamp(p.Duration))
 }
 
 // displaySearchResults is a helper function that calls the correct display
-// functions to print out all the search results
-func displaySearchResults(r *spotify.SearchResult) {
-	if len(r.Tracks.Tracks) > 0 {
-		displayFullTracks(r.Tracks.Tracks)
+// functions to print out all the search results, numbered from n
+// Results are numbered in the same order as getResultSlice()
+func displaySearchResults(r resultPage, n int) {
+	if r.Tracks != nil && len(r.Tracks.Tracks) > 0 {
+		displayFullTracks(r.Tracks.Tracks, n)
+		n += len(r.Tracks.Tracks)
 	}
-	if len(r.Artists.Artists) > 0 {
-		displayFullArtists(r.Artists.Artists)
+	if r.Artists != nil && len(r.Artists.Artists) > 0 {
+		displayFullArtists(r.Artists.Artists, n)
+		n += len(r.Artists.Artists)
 	}
-	if len(r.Albums.Albums) > 0 {
-		displaySimpleAlbums(r.Albums.Albums)
+	if r.Albums != nil && len(r.Albums.Albums) > 0 {
+		displaySimpleAlbums(r.Albums.Albums, n)
+		n += len(r.Albums.Albums)
 	}
-	if len(r.Playlists.Playlists) > 0 {
-		displaySimplePlaylists(r.Playlists.Playlists)
+	if r.Playlists != nil && len(r.Playlists.Playlists) > 0 {
+		displaySimplePlaylists(r.Playlists.Playlists, n)
+		n += len(r.Playlists.Playlists)
+	}
+	if len(r.Shows) > 0 {
+		displayShows(r.Shows, n)
+		n += len(r.Shows)
+	}
+	if len(r.Episodes) > 0 {
+		displayEpisodes(r.Episodes, n)
+	}
+}
+
+// displayEpisodes prints the name, show, and release date of each episode
+// in a []podcastEpisode, numbered from n
+func displayEpisodes(r []podcastEpisode, n int) {
+	fmt.Println("Episodes: ")
+	for i, v := range r {
+		fmt.Printf("  [%d]:\t\"%v\"", n+i, v.Name)
+		if v.Show.Name != "" {
+			fmt.Printf(" from %s", v.Show.Name)
+		}
+		fmt.Printf(" (%s)\n", v.ReleaseDate)
 	}
 }
 
 // displaySimpleAlbums prints a shortAlbumTemplate of each of the albums
-// in a []spotify.SimpleAlbum
-func displaySimpleAlbums(r []spotify.SimpleAlbum) {
-	client := auth.NewClient(tok)
+// in a []spotify.SimpleAlbum, numbered from n
+func displaySimpleAlbums(r []spotify.SimpleAlbum, n int) {
 	fmt.Println("Albums: ")
 	t := template.New("shortAlbumTemplate")
 	t, err := t.Parse(shortAlbumTemplate)
 	checkErr(err)
-	for i := 0; i < 5 && i < len(r); i++ {
-		v := r[i]
-		al, err := client.GetAlbum(v.ID)
-		checkErr(err)
-		fmt.Printf("  [%d]:\t", i+1)
-		err = t.Execute(os.Stdout, al)
+	for i, v := range r {
+		fmt.Printf("  [%d]:\t", n+i)
+		err = t.Execute(os.Stdout, v)
 		checkErr(err)
 	}
 }
 
 // displaySimplePlaylists prints the names and owner IDs of all the playlists
-// in a []spotify.SimplePlaylist
-func displaySimplePlaylists(r []spotify.SimplePlaylist) {
+// in a []spotify.SimplePlaylist, numbered from n
+func displaySimplePlaylists(r []spotify.SimplePlaylist, n int) {
 	fmt.Println("Playlists: ")
-	for i := 0; i < 5 && i < len(r); i++ {
-		v := r[i]
-		fmt.Printf("  [%d]:\t\"%v\" - %s\n", i+1, v.Name, v.Owner.ID)
+	for i, v := range r {
+		fmt.Printf("  [%d]:\t\"%v\" - %s\n", n+i, v.Name, v.Owner.ID)
+	}
+}
+
+// displayShows prints the names and publishers of each show
+// in a []podcastShow, numbered from n
+func displayShows(r []podcastShow, n int) {
+	fmt.Println("Shows: ")
+	for i, v := range r {
+		fmt.Printf("  [%d]:\t\"%v\" by %s\n", n+i, v.Name, v.Publisher)
 	}
 }
 
 // displayVolume prints the current volume level as a percent
-func displayVolume() {
-	v := getVolume()
+func displayVolume(ctx context.Context) {
+	v := getVolume(ctx)
 	if v == -1 {
 		return
 	}
 	fmt.Printf("Volume: %v%%\n", v)
 }
 
-// getActiveDeviceName returns the name of the actively playing device
-// Or "No devices active" if none are active
-func getActiveDeviceName() string {
-	client := auth.NewClient(tok)
-	d, err := client.PlayerDevices()
-	checkErr(err)
-	for _, v := range d {
-		if v.Active {
-			return v.Name
+// getArtistAlbums returns the albums by the artist with the specified ID
+// in release order, skipping albums with the same name as an earlier album
+func getArtistAlbums(ctx context.Context, id spotify.ID) []spotify.SimpleAlbum {
+	var al []spotify.SimpleAlbum
+	l := 50
+	t := spotify.AlbumTypeAlbum
+	client := newClient(ctx)
+	for o := 0; ; o += l {
+		p, err := client.GetArtistAlbumsOpt(id, &spotify.Options{Limit: &l, Offset: &o}, &t)
+		checkErr(err)
+		al = append(al, p.Albums...)
+		if p.Next == "" {
+			break
 		}
 	}
-	return "No devices active"
-}
-
-// getCurrentTrack returns a pointer to the currently playing track
-func getCurrentTrack() *spotify.FullTrack {
-	client := auth.NewClient(tok)
-	current, err := client.PlayerCurrentlyPlaying()
-	checkErr(err)
-	return current.Item
+	sort.SliceStable(al, func(i, j int) bool {
+		return al[i].ReleaseDate < al[j].ReleaseDate
+	})
+	seen := map[string]bool{}
+	r := al[:0]
+	for _, v := range al {
+		if n := strings.ToLower(v.Name); !seen[n] {
+			seen[n] = true
+			r = append(r, v)
+		}
+	}
+	return r
 }
 
 // getInterfaceSlice takes one of ([]spotify.FullTrack, []spotify.FullArtist,
@@ -654,11 +1146,172 @@ func getInterfaceSlice(r interface{}) []interface{} {
 	return nil
 }
 
+// getNextRepeat returns the repeat state after s when cycling through
+// off, context, and track
+func getNextRepeat(s string) string {
+	switch s {
+	case "off":
+		return "context"
+	case "context":
+		return track
+	}
+	return "off"
+}
+
+// getPageStart returns the number of the first result on page n of LastSearch
+func getPageStart(n int) int {
+	s := 1
+	for _, p := range searchPages[:n-1] {
+		s += len(getResultSlice(p))
+	}
+	return s
+}
+
+// getPlaybackOffset finds the track at in the album or playlist u
+// at may be a track number, a track URI, or part of a track name
+func getPlaybackOffset(ctx context.Context, u spotify.URI, t string, at string) (*spotify.PlaybackOffset, error) {
+	if t != album && t != plist {
+		return nil, fmt.Errorf("can only start albums and playlists at a track, not a %s", t)
+	}
+	if i, err := strconv.Atoi(at); err == nil {
+		if i < 1 {
+			return nil, fmt.Errorf("invalid track number: %d", i)
+		}
+		return &spotify.PlaybackOffset{Position: i - 1}, nil
+	}
+	if strings.HasPrefix(at, "spotify:") {
+		return &spotify.PlaybackOffset{URI: spotify.URI(at)}, nil
+	}
+	var names []spotify.SimpleTrack
+	client := newClient(ctx)
+	if t == album {
+		names = getAlbumTracks(ctx, getID(u))
+	} else {
+		l := 100
+		for o := 0; ; o += l {
+			p, err := client.GetPlaylistTracksOpt(getID(u), &spotify.Options{Limit: &l, Offset: &o}, "")
+			checkErr(err)
+			for _, v := range p.Tracks {
+				names = append(names, v.Track.SimpleTrack)
+			}
+			if p.Next == "" {
+				break
+			}
+		}
+	}
+	at = strings.ToLower(at)
+	for _, v := range names {
+		if strings.ToLower(v.Name) == at {
+			return &spotify.PlaybackOffset{URI: v.URI}, nil
+		}
+	}
+	for _, v := range names {
+		if strings.Contains(strings.ToLower(v.Name), at) {
+			return &spotify.PlaybackOffset{URI: v.URI}, nil
+		}
+	}
+	return nil, fmt.Errorf("no track matching \"%s\" in %s", at, u)
+}
+
+// getPlayStart returns where playback should begin from the --at and
+// --position flags of spotcon> play
+func getPlayStart(c *cli.Context) (playStart, error) {
+	st := playStart{At: c.String("at"), Mode: c.String("mode")}
+	switch st.Mode {
+	case "", "top", "discography", "radio":
+	default:
+		return st, fmt.Errorf("invalid mode: %s", st.Mode)
+	}
+	if c.IsSet("position") {
+		p, err := parsePosition(c.String("position"))
+		if err != nil {
+			return st, err
+		}
+		st.Position = p
+	}
+	return st, nil
+}
+
+// getResult returns result number i from r
+// If t is not empty the result must be of type t (artist, album, playlist, track)
+func getResult(r []interface{}, i int, t string) (interface{}, error) {
+	if len(r) == 0 {
+		return nil, fmt.Errorf("no search results found")
+	}
+	if i < 1 || i > len(r) {
+		return nil, fmt.Errorf("no result numbered %d, choose a number from 1 to %d", i, len(r))
+	}
+	if rt := getResultType(r[i-1]); t != "" && rt != t {
+		return nil, fmt.Errorf("result %d is a %s, not a %s", i, rt, t)
+	}
+	return r[i-1], nil
+}
+
+// getResultSlice returns every item in a page of search results as an
+// interface slice in the order (tracks, artists, albums, playlists, shows, episodes)
+func getResultSlice(r resultPage) []interface{} {
+	var s []interface{}
+	if r.Tracks != nil {
+		s = append(s, getInterfaceSlice(r.Tracks.Tracks)...)
+	}
+	if r.Artists != nil {
+		s = append(s, getInterfaceSlice(r.Artists.Artists)...)
+	}
+	if r.Albums != nil {
+		s = append(s, getInterfaceSlice(r.Albums.Albums)...)
+	}
+	if r.Playlists != nil {
+		s = append(s, getInterfaceSlice(r.Playlists.Playlists)...)
+	}
+	for _, v := range r.Shows {
+		s = append(s, v)
+	}
+	for _, v := range r.Episodes {
+		s = append(s, v)
+	}
+	return s
+}
+
+// getResultType returns the type of a result found with searchAction()
+// Returns one of (artist, album, playlist, track)
+func getResultType(r interface{}) string {
+	switch r.(type) {
+	case spotify.FullTrack:
+		return track
+	case spotify.FullArtist:
+		return artist
+	case spotify.SimpleAlbum:
+		return album
+	case spotify.SimplePlaylist:
+		return plist
+	case podcastShow:
+		return show
+	case podcastEpisode:
+		return episode
+	}
+	return ""
+}
+
+// getAlbumTracks returns every track of the album id
+func getAlbumTracks(ctx context.Context, id spotify.ID) []spotify.SimpleTrack {
+	var tr []spotify.SimpleTrack
+	client := newClient(ctx)
+	l := 50
+	for o := 0; ; o += l {
+		p, err := client.GetAlbumTracksOpt(id, l, o)
+		checkErr(err)
+		tr = append(tr, p.Tracks...)
+		if p.Next == "" {
+			return tr
+		}
+	}
+}
+
 // getSavedAlbums returns the first 50 of the user's saved artists
-func getSavedAlbums() []spotify.SavedAlbum {
+func getSavedAlbums(ctx context.Context) []spotify.SavedAlbum {
 	i := 50
 	o := spotify.Options{Limit: &i}
-	client := auth.NewClient(tok)
+	client := newClient(ctx)
 	s, err := client.CurrentUsersAlbumsOpt(&o)
 	checkErr(err)
 	sa := s.Albums
@@ -666,10 +1319,10 @@ func getSavedAlbums() []spotify.SavedAlbum {
 }
 
 // getSavedPlaylists returns the first 50 of the user's saved playlists
-func getSavedPlaylists() []spotify.SimplePlaylist {
+func getSavedPlaylists(ctx context.Context) []spotify.SimplePlaylist {
 	i := 50
 	o := spotify.Options{Limit: &i}
-	client := auth.NewClient(tok)
+	client := newClient(ctx)
 	s, err := client.CurrentUsersPlaylistsOpt(&o)
 	checkErr(err)
 	sa := s.Playlists
@@ -677,38 +1330,127 @@ func getSavedPlaylists() []spotify.SimplePlaylist {
 }
 
 // getSavedTracks returns the first 50 of the user's saved tracks
-func getSavedTracks() []spotify.SavedTrack {
+func getSavedTracks(ctx context.Context) []spotify.SavedTrack {
 	i := 50
 	o := spotify.Options{Limit: &i}
-	client := auth.NewClient(tok)
+	client := newClient(ctx)
 	s, err := client.CurrentUsersTracksOpt(&o)
 	checkErr(err)
 	sa := s.Tracks
 	return sa
 }
 
+// getSearchQuery builds a query from the arguments and field filter flags of
+// spotcon> search using Spotify's field filter syntax
+// e.g. search --artist-is "Alter Bridge" --year 2010-2019 blackbird
+// becomes: blackbird artist:"Alter Bridge" year:2010-2019
+func getSearchQuery(c *cli.Context) (string, error) {
+	q := []string{}
+	if c.NArg() > 0 {
+		q = append(q, strings.Join(c.Args(), " "))
+	}
+	for _, f := range []string{"artist-is", "album-is", "genre"} {
+		if v := c.String(f); v != "" {
+			v = strings.Replace(v, "\"", "", -1)
+			q = append(q, fmt.Sprintf("%s:\"%s\"", strings.TrimSuffix(f, "-is"), v))
+		}
+	}
+	if y := c.String("year"); y != "" {
+		if !yearRange.MatchString(y) {
+			return "", fmt.Errorf("invalid year or range of years: %s", y)
+		}
+		q = append(q, "year:"+y)
+	}
+	if c.Bool("new") {
+		q = append(q, "tag:new")
+	}
+	if c.Bool("hipster") {
+		q = append(q, "tag:hipster")
+	}
+	if m := c.String("market"); m != "" && !market.MatchString(m) {
+		return "", fmt.Errorf("invalid market: %s", m)
+	}
+	return strings.Join(q, " "), nil
+}
+
+// getSearchPageArg returns the page of the last search asked for by
+// search more or search page NUMBER, or 0 if c is a new search
+// Any flags or other arguments make it a search for those words
+func getSearchPageArg(c *cli.Context) int {
+	if c.NumFlags() > 0 {
+		return 0
+	}
+	switch {
+	case c.NArg() == 1 && c.Args().First() == "more":
+		return len(searchPages) + 1
+	case c.NArg() == 2 && c.Args().First() == "page":
+		n, err := strconv.Atoi(c.Args().Get(1))
+		if err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// getSearchTotal returns the largest number of results available for any
+// of the types in a search result
+func getSearchTotal(r *spotify.SearchResult) int {
+	t := 0
+	if r.Tracks != nil && r.Tracks.Total > t {
+		t = r.Tracks.Total
+	}
+	if r.Artists != nil && r.Artists.Total > t {
+		t = r.Artists.Total
+	}
+	if r.Albums != nil && r.Albums.Total > t {
+		t = r.Albums.Total
+	}
+	if r.Playlists != nil && r.Playlists.Total > t {
+		t = r.Playlists.Total
+	}
+	return t
+}
+
+// getTimestamp formats a position of t milliseconds as m:ss or h:mm:ss
+func getTimestamp(t int) string {
+	t /= 1000
+	if t >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", t/3600, t/60%60, t%60)
+	}
+	return fmt.Sprintf("%d:%02d", t/60, t%60)
+}
+
 // getURI accesses the URI property of the interface
 // Input must be one of [spotify.FullTrack, spotify.SimplePlaylist,
-//                       spotify.SimpleAlbum, spotify.FullArtist]
+//                       spotify.SimpleAlbum, spotify.FullArtist,
+//                       podcastShow, podcastEpisode]
 func getURI(r interface{}) spotify.URI {
 	switch r := r.(type) {
 	case spotify.FullTrack:
 		return r.URI
+	case *spotify.FullTrack:
+		if r != nil {
+			return r.URI
+		}
 	case spotify.SimplePlaylist:
 		return r.URI
 	case spotify.SimpleAlbum:
 		return r.URI
 	case spotify.FullArtist:
 		return r.URI
+	case podcastShow:
+		return r.URI
+	case podcastEpisode:
+		return r.URI
 	}
 	return ""
 }
 
 // getVolume retrieves the current volume level
 // Returns an integer between 0 and 100
-func getVolume() int {
+func getVolume(ctx context.Context) int {
 	a := -1
-	client := auth.NewClient(tok)
+	client := newClient(ctx)
 	d, err := client.PlayerDevices()
 	checkErr(err)
 	for _, v := range d {
@@ -723,58 +1465,194 @@ func getVolume() int {
 	return a
 }
 
-// setDevice transfers playback to a new device
-// Either takes the name of a device as input or the number
-// displayed from devicesAction()
-func setDevice(s string) bool {
-	client := auth.NewClient(tok)
-	d, err := client.PlayerDevices()
-	checkErr(err)
-	var xi int
-	if xi, err = strconv.Atoi(s); err == nil {
-		if xi > 0 && xi <= len(d) {
-			err = client.Pause() // Pause playback before transfer.
-			checkErr(err)
-			err = client.TransferPlayback(d[xi-1].ID, false)
-			checkErr(err)
-			return true
+// parseNumbers parses a list of numbers and ranges of numbers e.g. 1,3,5-7
+// Every number must be from 1 to max, so a name such as "1-800" isn't
+// taken for a huge range
+func parseNumbers(s string, max int) ([]int, error) {
+	var n []int
+	for _, v := range strings.Split(s, ",") {
+		r := strings.SplitN(strings.TrimSpace(v), "-", 2)
+		i, err := strconv.Atoi(r[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", v)
+		}
+		j := i
+		if len(r) == 2 {
+			if j, err = strconv.Atoi(r[1]); err != nil || j < i {
+				return nil, fmt.Errorf("invalid range: %s", v)
+			}
+		}
+		if i < 1 || j > max {
+			return nil, fmt.Errorf("%s is not between 1 and %d", v, max)
+		}
+		for ; i <= j; i++ {
+			n = append(n, i)
 		}
-		fmt.Println("ERROR: Incorrect device ID, ", s)
-		return false
 	}
+	return n, nil
+}
 
-	for _, v := range d {
-		if strings.Contains(strings.ToLower(v.Name), strings.ToLower(s)) {
-			err = client.Pause() // Pause playback before transfer.
-			checkErr(err)
-			err = client.TransferPlayback(v.ID, false)
-			checkErr(err)
-			return true
+// parsePosition parses a position in playback written as seconds, m:ss or h:mm:ss
+// Returns the position in milliseconds
+func parsePosition(s string) (int, error) {
+	t := 0
+	p := strings.Split(s, ":")
+	if len(p) > 3 {
+		return 0, fmt.Errorf("invalid position: %s", s)
+	}
+	for i, v := range p {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || (i > 0 && n > 59) {
+			return 0, fmt.Errorf("invalid position: %s", s)
 		}
+		t = t*60 + n
 	}
+	return t * 1000, nil
+}
+
+// parseRepeat parses a repeat state, one of (track, context, off)
+// "on" is accepted for context
+func parseRepeat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case track, "context", "off":
+		return strings.ToLower(s), nil
+	case "on":
+		return "context", nil
+	}
+	return "", fmt.Errorf("repeat must be one of (track, context, off), not %s", s)
+}
+
+// parseSeekPosition parses a position (m:ss, h:mm:ss) or a percent (50%)
+// of a track that is d milliseconds long
+// Returns the position in milliseconds
+func parseSeekPosition(s string, d int) (int, error) {
+	if strings.HasSuffix(s, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || f < 0 || f > 100 {
+			return 0, fmt.Errorf("invalid percent: %s", s)
+		}
+		return int(float64(d) * f / 100), nil
+	}
+	return parsePosition(s)
+}
 
-	fmt.Println("ERROR: Could not connect to device, ", s)
-	return false
+// setDevice transfers playback to a new device
+// Takes an alias, the name of a device, or the number displayed from
+// devicesAction() as input
+func setDevice(ctx context.Context, s string) error {
+	client := newClient(ctx)
+	cfg := loadConfig()
+	v, err := findDevice(getDevices(ctx), s, cfg)
+	if err != nil {
+		return fmt.Errorf("could not connect to device, %v", err)
+	}
+	if cur := getActiveDevice(ctx); cur != nil {
+		err = client.Pause() // Pause playback before transfer.
+		checkErr(err)
+		cfg.Volumes[cur.ID] = cur.Volume
+	}
+	err = client.TransferPlayback(v.ID, false)
+	checkErr(err)
+	restoreVolume(ctx, v, cfg)
+	return nil
 }
 
-// setRepeat sets repeat option to one of [on, off]
-func setRepeat(s string) {
-	client := auth.NewClient(tok)
+// setRepeat sets repeat option to one of [track, context, off]
+func setRepeat(ctx context.Context, s string) {
+	client := newClient(ctx)
 	err := client.Repeat(s)
 	checkErr(err)
 }
 
+// setSearchResults replaces LastSearch with r so its items can be played
+// by number. Used for listings such as recommendations that have no
+// further pages
+func setSearchResults(r *spotify.SearchResult) {
+	LastQuery = searchQuery{}
+	LastSearch = r
+	searchPages = []resultPage{{SearchResult: r}}
+	LastResults = getResultSlice(searchPages[0])
+}
+
 // setShuffle sets shuffle option to one of [on, off]
-func setShuffle(b bool) {
-	client := auth.NewClient(tok)
+func setShuffle(ctx context.Context, b bool) {
+	client := newClient(ctx)
 	err := client.Shuffle(b)
 	checkErr(err)
 }
 
 // setVolume sets volume to a percent
-// 0 < i < 100
-func setVolume(i int) {
-	client := auth.NewClient(tok)
+// 0 < i < 100, limited to the maximum volume of the active device
+// The volume is remembered so it can be restored after a transfer
+// Returns the volume that was set
+func setVolume(ctx context.Context, i int) int {
+	client := newClient(ctx)
+	cfg := loadConfig()
+	d := getActiveDevice(ctx)
+	if d != nil {
+		if m, ok := cfg.MaxVolumes[d.ID]; ok && i > m {
+			fmt.Printf("Volume is limited to %d%% on %s.\n", m, d.Name)
+			i = m
+		}
+	}
 	err := client.Volume(i)
 	checkErr(err)
+	if d != nil {
+		cfg.Volumes[d.ID] = i
+		saveConfig(cfg)
+	}
+	return i
+}
+
+// waitForPosition waits until playback is at ms milliseconds into the
+// current item, allowing for playback that continues while waiting
+func waitForPosition(ctx context.Context, ms int) error {
+	start := time.Now()
+	return waitForState(ctx, "position", func(s *spotify.PlayerState) bool {
+		d := s.Progress - ms
+		return d > -1000 && d < 1000+int(time.Since(start)/time.Millisecond)
+	})
+}
+
+// waitForState polls the player state until done returns true for it
+// Returns an error naming the what setting if done is still false after
+// stateTimeout
+func waitForState(ctx context.Context, what string, done func(*spotify.PlayerState) bool) error {
+	client := newClient(ctx)
+	end := time.Now().Add(stateTimeout)
+	for {
+		state, err := client.PlayerState()
+		checkErr(err)
+		if done(state) {
+			return nil
+		}
+		if time.Now().After(end) {
+			return fmt.Errorf("Spotify did not confirm the %s change after %v", what, stateTimeout)
+		}
+		if !sleepCommand(ctx, statePoll) {
+			panic(errInterrupted)
+		}
+	}
+}
+
+// waitForVolume waits until the volume of the active device is i percent
+func waitForVolume(ctx context.Context, i int) error {
+	return waitForState(ctx, "volume", func(s *spotify.PlayerState) bool { return s.Device.Volume == i })
+}
+
+// watchNow redraws "Now Playing" every interval until Ctrl-C is pressed
+func watchNow(ctx context.Context, interval time.Duration) {
+	if interval < time.Second {
+		interval = time.Second
+	}
+	for {
+		if isTerminal(os.Stdout) {
+			fmt.Print("\x1b[H\x1b[2J")
+		}
+		displayNow(ctx)
+		fmt.Println("\nPress Ctrl-C to stop watching.")
+		if !sleepCommand(ctx, interval) {
+			return
+		}
+	}
 }
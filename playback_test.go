@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestGetResult(t *testing.T) {
+	r := []interface{}{
+		spotify.FullTrack{SimpleTrack: spotify.SimpleTrack{Name: "first"}},
+		spotify.FullArtist{SimpleArtist: spotify.SimpleArtist{Name: "second"}},
+		spotify.FullTrack{SimpleTrack: spotify.SimpleTrack{Name: "last"}},
+	}
+	tests := []struct {
+		name string
+		r    []interface{}
+		i    int
+		t    string
+		want interface{}
+		err  bool
+	}{
+		{"no results", nil, 1, "", nil, true},
+		{"zero", r, 0, "", nil, true},
+		{"first", r, 1, "", r[0], false},
+		{"last", r, 3, "", r[2], false},
+		{"past the end", r, 4, "", nil, true},
+		{"matching type", r, 2, artist, r[1], false},
+		{"type mismatch", r, 2, track, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := getResult(tt.r, tt.i, tt.t)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: getResult(%d, %q) error = %v", tt.name, tt.i, tt.t, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: getResult(%d, %q) = %v, want %v", tt.name, tt.i, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []int
+		err  bool
+	}{
+		{"3", []int{3}, false},
+		{"1,3,5", []int{1, 3, 5}, false},
+		{"2-4", []int{2, 3, 4}, false},
+		{"1, 3-5", []int{1, 3, 4, 5}, false},
+		{"4-4", []int{4}, false},
+		{"", nil, true},
+		{"a", nil, true},
+		{"1,", nil, true},
+		{"5-3", nil, true},
+		{"1-b", nil, true},
+		{"0-2", nil, true},
+		{"9-10", nil, true},
+		{"1-800", nil, true},
+		{"1-2000000000", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseNumbers(tt.s, 9)
+		if (err != nil) != tt.err {
+			t.Errorf("parseNumbers(%q) error = %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNumbers(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestGetPageStart(t *testing.T) {
+	defer func(p []resultPage) { searchPages = p }(searchPages)
+	searchPages = []resultPage{
+		{SearchResult: &spotify.SearchResult{
+			Tracks:  &spotify.FullTrackPage{Tracks: make([]spotify.FullTrack, 5)},
+			Artists: &spotify.FullArtistPage{Artists: make([]spotify.FullArtist, 2)},
+		}},
+		{
+			SearchResult: &spotify.SearchResult{
+				Tracks: &spotify.FullTrackPage{Tracks: make([]spotify.FullTrack, 5)},
+			},
+			Shows: make([]podcastShow, 1),
+		},
+		{SearchResult: &spotify.SearchResult{
+			Tracks: &spotify.FullTrackPage{Tracks: make([]spotify.FullTrack, 3)},
+		}},
+	}
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{1, 1},
+		{2, 8},
+		{3, 14},
+	}
+	for _, tt := range tests {
+		if got := getPageStart(tt.n); got != tt.want {
+			t.Errorf("getPageStart(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
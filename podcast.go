@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zmb3/spotify"
+)
+
+// apiURL is the base URL of the Spotify Web API
+const apiURL = "https://api.spotify.com/v1/"
+
+// podcastEpisode is an episode of a podcast
+type podcastEpisode struct {
+	ID          spotify.ID  `json:"id"`
+	URI         spotify.URI `json:"uri"`
+	Name        string      `json:"name"`
+	Duration    int         `json:"duration_ms"`
+	ReleaseDate string      `json:"release_date"`
+	Show        podcastShow `json:"show"`
+	ResumePoint struct {
+		FullyPlayed bool `json:"fully_played"`
+		Position    int  `json:"resume_position_ms"`
+	} `json:"resume_point"`
+}
+
+// podcastShow is a podcast
+type podcastShow struct {
+	ID        spotify.ID  `json:"id"`
+	URI       spotify.URI `json:"uri"`
+	Name      string      `json:"name"`
+	Publisher string      `json:"publisher"`
+}
+
+// playingItem is the track or episode that is currently playing
+type playingItem struct {
+	URI      spotify.URI
+	Name     string
+	Duration int
+	Progress int
+	Playing  bool
+	Context  spotify.PlaybackContext
+	Track    *spotify.FullTrack // Set when a track is playing
+	Episode  *podcastEpisode    // Set when an episode is playing
+}
+
+// getJSON makes a GET request to the Web API endpoint path and decodes the
+// response into v. Used for endpoints the spotify package does not cover
+// Returns false if the response has no content
+func getJSON(ctx context.Context, path string, q url.Values, v interface{}) (bool, error) {
+	resp, err := newHTTPClient(ctx).Get(apiURL + path + "?" + q.Encode())
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error spotify.Error `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error.Message == "" {
+			return false, fmt.Errorf("spotify: %s", resp.Status)
+		}
+		return false, e.Error
+	}
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getEpisode returns the episode with the specified ID
+func getEpisode(ctx context.Context, id spotify.ID) *podcastEpisode {
+	var e podcastEpisode
+	_, err := getJSON(ctx, "episodes/"+string(id), url.Values{"market": {"from_token"}}, &e)
+	checkErr(err)
+	return &e
+}
+
+// getPlayingItem returns the track or episode that is currently playing
+// Returns nil if nothing is playing
+func getPlayingItem(ctx context.Context) *playingItem {
+	i, err := fetchPlayingItem(ctx)
+	checkErr(err)
+	return i
+}
+
+// fetchPlayingItem is getPlayingItem for callers outside of a command,
+// which must handle the error themselves
+func fetchPlayingItem(ctx context.Context) (*playingItem, error) {
+	var p struct {
+		Progress int                     `json:"progress_ms"`
+		Playing  bool                    `json:"is_playing"`
+		Type     string                  `json:"currently_playing_type"`
+		Context  spotify.PlaybackContext `json:"context"`
+		Item     json.RawMessage         `json:"item"`
+	}
+	ok, err := getJSON(ctx, "me/player/currently-playing", url.Values{"additional_types": {"track,episode"}}, &p)
+	if err != nil || !ok || len(p.Item) == 0 || string(p.Item) == "null" {
+		return nil, err
+	}
+	i := &playingItem{Progress: p.Progress, Playing: p.Playing, Context: p.Context}
+	switch p.Type {
+	case track:
+		i.Track = &spotify.FullTrack{}
+		if err = json.Unmarshal(p.Item, i.Track); err != nil {
+			return nil, err
+		}
+		i.URI, i.Name, i.Duration = i.Track.URI, i.Track.Name, i.Track.Duration
+	case episode:
+		i.Episode = &podcastEpisode{}
+		if err = json.Unmarshal(p.Item, i.Episode); err != nil {
+			return nil, err
+		}
+		i.URI, i.Name, i.Duration = i.Episode.URI, i.Episode.Name, i.Episode.Duration
+	default:
+		return nil, nil
+	}
+	return i, nil
+}
+
+// getSavedShows returns the first 50 of the user's saved shows
+func getSavedShows(ctx context.Context) []podcastShow {
+	var r struct {
+		Items []struct {
+			Show podcastShow `json:"show"`
+		} `json:"items"`
+	}
+	_, err := getJSON(ctx, "me/shows", url.Values{"limit": {"50"}}, &r)
+	checkErr(err)
+	var s []podcastShow
+	for _, v := range r.Items {
+		s = append(s, v.Show)
+	}
+	return s
+}
+
+// searchPodcasts searches Spotify for shows and episodes
+// t is a list of the types to search for, any of (show, episode)
+// Returns the shows and episodes found and the largest total of either
+func searchPodcasts(ctx context.Context, q string, t []string, market string, limit int, offset int) ([]podcastShow, []podcastEpisode, int) {
+	var r struct {
+		Shows struct {
+			Items []podcastShow `json:"items"`
+			Total int           `json:"total"`
+		} `json:"shows"`
+		Episodes struct {
+			Items []podcastEpisode `json:"items"`
+			Total int              `json:"total"`
+		} `json:"episodes"`
+	}
+	if market == "" {
+		market = "from_token"
+	}
+	v := url.Values{
+		"q":      {q},
+		"type":   {strings.Join(t, ",")},
+		"market": {market},
+		"limit":  {strconv.Itoa(limit)},
+		"offset": {strconv.Itoa(offset)},
+	}
+	_, err := getJSON(ctx, "search", v, &r)
+	checkErr(err)
+	total := r.Shows.Total
+	if r.Episodes.Total > total {
+		total = r.Episodes.Total
+	}
+	return r.Shows.Items, r.Episodes.Items, total
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// lateness is how late a job can run and still count as on time
+const lateness = 2 * time.Minute
+
+// weekdays are the names accepted for days in a schedule
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// daemonRunning is true while the daemon runs jobs
+// Work left running in the background by a job outlives it
+var daemonRunning bool
+
+// scheduledJob is a list of spotcon commands run at a time of day
+type scheduledJob struct {
+	ID       int
+	At       string    // Time of day as 15:04
+	Days     string    // daily, weekdays, weekends, or days such as mon,wed,fri
+	Missed   string    // What to do when a run was missed: skip, run, or a duration
+	Commands []string  // spotcon command lines to run in order
+	LastRun  time.Time // When the job last ran, or was added
+}
+
+// daemonAction is called with spotcon daemon
+// Runs scheduled jobs until stopped
+func daemonAction(c *cli.Context) error {
+	ctx := getContext(c)
+	// Never wait on a pager while running jobs
+	defer func(b bool) { interactive = b }(interactive)
+	interactive = false
+	daemonRunning = true
+	defer func() { daemonRunning = false }()
+	fmt.Println("Running scheduled jobs, press Ctrl-C to stop.")
+	for {
+		now := time.Now()
+		for _, j := range loadConfig().Schedule {
+			prev := getLastRunTime(j, now)
+			if !prev.After(j.LastRun) {
+				continue
+			}
+			late := now.Sub(prev)
+			if late > lateness && !runMissed(j.Missed, late) {
+				fmt.Printf("Skipping job %d, missed at %s\n", j.ID, prev.Format("Mon 15:04"))
+			} else {
+				runJob(c.App, j)
+			}
+			// Reload in case the schedule changed while the job ran
+			cfg := loadConfig()
+			for i := range cfg.Schedule {
+				if cfg.Schedule[i].ID == j.ID {
+					cfg.Schedule[i].LastRun = now
+				}
+			}
+			saveConfig(cfg)
+		}
+		if !sleepCommand(ctx, 20*time.Second) {
+			return nil
+		}
+	}
+}
+
+// scheduleAddAction is called with spotcon> schedule add
+// Adds a job that runs each COMMAND at the time set by --at
+func scheduleAddAction(c *cli.Context) error {
+	if c.NArg() == 0 || !c.IsSet("at") {
+		return showUsage(c)
+	}
+	j := scheduledJob{
+		At:       c.String("at"),
+		Days:     strings.ToLower(c.String("days")),
+		Missed:   strings.ToLower(c.String("missed")),
+		Commands: c.Args(),
+		LastRun:  time.Now(),
+	}
+	if _, err := time.Parse("15:04", j.At); err != nil {
+		return fmt.Errorf("--at must be a time such as 07:00, not %s", j.At)
+	}
+	if _, err := parseDays(j.Days); err != nil {
+		return err
+	}
+	if _, err := parseMissed(j.Missed); err != nil {
+		return err
+	}
+	// A typo would only show up when the job runs, so catch it now
+	for _, v := range j.Commands {
+		if err := checkCommand(getRootApp(c), v); err != nil {
+			return fmt.Errorf("%s: %v", v, err)
+		}
+	}
+	cfg := loadConfig()
+	for _, v := range cfg.Schedule {
+		if v.ID >= j.ID {
+			j.ID = v.ID + 1
+		}
+	}
+	if j.ID == 0 {
+		j.ID = 1
+	}
+	cfg.Schedule = append(cfg.Schedule, j)
+	saveConfig(cfg)
+	fmt.Printf("Added job %d, next run %s\n", j.ID, getNextRunTime(j, time.Now()).Format("Mon Jan 2 15:04"))
+	return nil
+}
+
+// scheduleListAction is called with spotcon> schedule list
+// Prints each scheduled job
+func scheduleListAction(c *cli.Context) error {
+	cfg := loadConfig()
+	if len(cfg.Schedule) == 0 {
+		fmt.Println("No jobs scheduled.")
+		return nil
+	}
+	now := time.Now()
+	fmt.Println("Schedule:")
+	for _, j := range cfg.Schedule {
+		fmt.Printf("  [%d]: %s %s (missed: %s), next run %s\n", j.ID, j.At, j.Days, j.Missed,
+			getNextRunTime(j, now).Format("Mon Jan 2 15:04"))
+		for _, v := range j.Commands {
+			fmt.Println("         ", v)
+		}
+	}
+	return nil
+}
+
+// scheduleRmAction is called with spotcon> schedule rm
+// Removes the job numbered ID
+func scheduleRmAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	id, err := strconv.Atoi(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("job ID must be a number, not %s", c.Args().First())
+	}
+	cfg := loadConfig()
+	for i, v := range cfg.Schedule {
+		if v.ID == id {
+			cfg.Schedule = append(cfg.Schedule[:i], cfg.Schedule[i+1:]...)
+			saveConfig(cfg)
+			return nil
+		}
+	}
+	return fmt.Errorf("no job numbered %v", id)
+}
+
+// getLastRunTime returns the latest time j was due to run, up to now
+func getLastRunTime(j scheduledJob, now time.Time) time.Time {
+	for i := 0; i <= 7; i++ {
+		t := getRunTime(j, now.AddDate(0, 0, -i))
+		if !t.IsZero() && !t.After(now) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// getNextRunTime returns the next time j is due to run after now
+func getNextRunTime(j scheduledJob, now time.Time) time.Time {
+	for i := 0; i <= 7; i++ {
+		t := getRunTime(j, now.AddDate(0, 0, i))
+		if t.After(now) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// getRunTime returns the time j runs on the day of d
+// Returns the zero time if j does not run that day
+func getRunTime(j scheduledJob, d time.Time) time.Time {
+	at, err := time.Parse("15:04", j.At)
+	if err != nil {
+		return time.Time{}
+	}
+	days, err := parseDays(j.Days)
+	if err != nil || !days[d.Weekday()] {
+		return time.Time{}
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), at.Hour(), at.Minute(), 0, 0, d.Location())
+}
+
+// parseDays parses the days of a schedule
+// Accepts daily, weekdays, weekends, or a list of days such as mon,wed,fri
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	switch s {
+	case "daily":
+		return [7]bool{true, true, true, true, true, true, true}, nil
+	case "weekdays":
+		return [7]bool{false, true, true, true, true, true, false}, nil
+	case "weekends":
+		return [7]bool{true, false, false, false, false, false, true}, nil
+	}
+	for _, v := range strings.Split(s, ",") {
+		found := false
+		for i, d := range weekdays {
+			if strings.HasPrefix(strings.TrimSpace(v), d) {
+				days[i], found = true, true
+			}
+		}
+		if !found {
+			return days, fmt.Errorf("days must be daily, weekdays, weekends, or a list such as mon,wed,fri, not %s", s)
+		}
+	}
+	return days, nil
+}
+
+// parseMissed parses the missed-run policy of a schedule
+// Returns how late a missed run can be and still run
+func parseMissed(s string) (time.Duration, error) {
+	switch s {
+	case "skip":
+		return 0, nil
+	case "run":
+		return -1, nil
+	}
+	d, err := parseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("--missed must be skip, run, or a duration such as 30m, not %s", s)
+	}
+	return d, nil
+}
+
+// checkCommand returns an error if line doesn't begin with one of the
+// commands of app, followed by a subcommand for commands that need one
+func checkCommand(app *cli.App, line string) error {
+	args := splitArgs(line)
+	if len(args) == 0 {
+		return errors.New("empty command")
+	}
+	cmd := app.Command(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	if cmd.Action != nil || len(cmd.Subcommands) == 0 {
+		return nil
+	}
+	if len(args) > 1 {
+		for _, v := range cmd.Subcommands {
+			if v.HasName(args[1]) {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown command %q", args[0]+" "+args[1])
+	}
+	return fmt.Errorf("%s needs a subcommand", args[0])
+}
+
+// getRootApp returns the app that runs the top level commands, which a
+// subcommand's context doesn't have
+func getRootApp(c *cli.Context) *cli.App {
+	for c.Parent() != nil {
+		c = c.Parent()
+	}
+	return c.App
+}
+
+// runJob runs each of the commands in j
+// Stops at the first command that fails, leaving the daemon running
+func runJob(app *cli.App, j scheduledJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == errInterrupted {
+				panic(r)
+			}
+			fmt.Printf("ERROR: Job %d: %v\n", j.ID, r)
+		}
+	}()
+	fmt.Printf("\n%s Running job %d\n", time.Now().Format("Mon Jan 2 15:04"), j.ID)
+	for _, v := range j.Commands {
+		fmt.Println("\nspotcon>", v)
+		if err := runLine(app, v); err != nil {
+			fmt.Printf("ERROR: Job %d: %v\n", j.ID, err)
+			return
+		}
+	}
+}
+
+// runMissed returns true if a run missed by late should still run
+// under the missed-run policy s
+func runMissed(s string, late time.Duration) bool {
+	d, err := parseMissed(s)
+	if err != nil {
+		return false
+	}
+	return d < 0 || late <= d
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/urfave/cli"
+)
+
+// splitArgs splits a command line into arguments on whitespace
+// Text enclosed in quotes is kept together as a single argument and the
+// enclosing quotes are removed
+func splitArgs(line string) []string {
+	lastQuote := rune(0)
+	f := func(c rune) bool {
+		switch {
+		case c == lastQuote:
+			lastQuote = rune(0)
+			return false
+		case lastQuote != rune(0):
+			return false
+		case unicode.In(c, unicode.Quotation_Mark):
+			lastQuote = c
+			return false
+		default:
+			return unicode.IsSpace(c)
+		}
+	}
+	args := strings.FieldsFunc(line, f)
+	for i, v := range args {
+		args[i] = trimQuotes(v)
+	}
+	return args
+}
+
+// trimQuotes removes a matching pair of quotes surrounding s
+func trimQuotes(s string) string {
+	r := []rune(s)
+	if len(r) < 2 {
+		return s
+	}
+	if unicode.In(r[0], unicode.Quotation_Mark) && r[0] == r[len(r)-1] {
+		return string(r[1 : len(r)-1])
+	}
+	return s
+}
+
+// runLine executes a single spotcon command line
+func runLine(app *cli.App, line string) error {
+	return runArgs(app, append([]string{"spotcon"}, splitArgs(line)...))
+}
+
+// runArgs runs app with args, returning the error of a command stopped by
+// checkErr instead of quitting
+func runArgs(app *cli.App, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(commandError)
+			if !ok {
+				panic(r)
+			}
+			err = e.err
+		}
+	}()
+	return app.Run(args)
+}
+
+// runScript executes each line of the file at path as a spotcon command
+//   - blank lines and lines beginning with # are ignored
+//   - "wait DURATION" pauses the script (e.g. wait 30s, wait 1m30s)
+//   - "onerror stop|continue" changes how errors in later lines are handled
+//
+// sleep is always the sleep command, so "sleep 30m" starts the sleep timer
+//
+// The script stops at the first failing line unless keepGoing is true
+// Its wait directives end early if ctx is cancelled
+func runScript(ctx context.Context, app *cli.App, path string, keepGoing bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Never wait on a pager while running a script
+	defer func(b bool) { interactive = b }(interactive)
+	interactive = false
+
+	s := bufio.NewScanner(file)
+	n := 0
+	for s.Scan() {
+		n++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args := splitArgs(line)
+		switch {
+		case args[0] == "wait":
+			err = scriptWait(ctx, args[1:])
+		case args[0] == "onerror":
+			keepGoing, err = scriptOnError(args[1:])
+		default:
+			fmt.Println("\nspotcon>", line)
+			err = runLine(app, line)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s:%d: %v", path, n, err)
+			if !keepGoing {
+				return err
+			}
+			fmt.Println("ERROR:", err)
+		}
+	}
+	return s.Err()
+}
+
+// scriptWait handles the wait directive of a script
+func scriptWait(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: wait DURATION")
+	}
+	d, err := parseDuration(args[0])
+	if err != nil {
+		return err
+	}
+	if !sleepCommand(ctx, d) {
+		panic(errInterrupted)
+	}
+	return nil
+}
+
+// parseDuration parses a Go duration (30s, 1m30s) or a whole number of seconds
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		i, e := strconv.Atoi(s)
+		if e != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		d = time.Duration(i) * time.Second
+	}
+	return d, nil
+}
+
+// scriptOnError handles the onerror directive of a script
+// Returns true if the script should continue after a failing line
+func scriptOnError(args []string) (bool, error) {
+	if len(args) == 1 {
+		switch args[0] {
+		case "stop":
+			return false, nil
+		case "continue":
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("usage: onerror stop|continue")
+}
+
+// sourceAction is called with spotcon> source
+// Runs the spotcon commands found in FILE
+func sourceAction(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	return runScript(getContext(c), c.App, c.Args().First(), c.Bool("keep-going"))
+}
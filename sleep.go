@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/zmb3/spotify"
+)
+
+// fadeStep is the shortest time between volume changes during a fade
+// Changing volume any faster quickly runs into the API rate limit
+const fadeStep = 500 * time.Millisecond
+
+// errStopped is returned when a fade is stopped before it finishes
+var errStopped = errors.New("stopped")
+
+var (
+	sleeping  *sleepTimer // The running sleep timer, if any
+	sleepLock sync.Mutex
+)
+
+// sleepTimer fades out and pauses playback when it goes off
+type sleepTimer struct {
+	When  string    // Description of when the timer goes off
+	Until time.Time // Time the timer goes off, if it is known
+	stop  chan struct{}
+}
+
+// sleepAction is called with spotcon> sleep
+// Fades out and pauses playback after DURATION or at the end of the current
+// track or album. Shows the running timer if neither is given
+func sleepAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 1 || (c.NArg() == 1 && c.IsSet("end")) {
+		return showUsage(c)
+	}
+	fade, err := parseDuration(c.String("fade"))
+	if err != nil {
+		return err
+	}
+	t := &sleepTimer{stop: make(chan struct{})}
+	// The timer's requests have their own context, so pressing Ctrl-C during
+	// a later command doesn't cancel them. sleep cancel stops the timer
+	tctx := context.Background()
+	var remaining func() (time.Duration, bool, error)
+	var poll time.Duration
+	switch {
+	case c.NArg() == 1:
+		d, err := parseDuration(c.Args().First())
+		if err != nil {
+			return err
+		}
+		t.Until = time.Now().Add(d)
+		t.When = "at " + t.Until.Format("15:04:05")
+		remaining = func() (time.Duration, bool, error) { return time.Until(t.Until), true, nil }
+	case c.String("end") == track:
+		item := getPlayingItem(ctx)
+		if item == nil {
+			return errNothingPlaying
+		}
+		t.When = "at the end of " + item.Name
+		remaining, poll = getTrackRemaining(tctx, item.URI), 5*time.Second
+	case c.String("end") == album:
+		item := getPlayingItem(ctx)
+		if item == nil || item.Track == nil {
+			return errors.New("no track is playing")
+		}
+		t.When = "at the end of " + item.Track.Album.Name
+		// The tracks are fetched now so Ctrl-C can cancel it, then the timer
+		// only polls what is playing
+		tr := getAlbumTracks(ctx, item.Track.Album.ID)
+		remaining, poll = getAlbumRemaining(tctx, item.Track, tr), 5*time.Second
+	case c.IsSet("end"):
+		return fmt.Errorf("--end must be one of (track, album), not %s", c.String("end"))
+	default:
+		displaySleepTimer()
+		return nil
+	}
+	stopSleepTimer()
+	sleepLock.Lock()
+	sleeping = t
+	sleepLock.Unlock()
+	fmt.Println("Sleep timer: pausing", t.When)
+	// A background timer would be lost when spotcon exits, so wait for it
+	// unless commands are being read from the prompt or by the daemon
+	if !interactive && !daemonRunning {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				stopSleepTimer()
+				fmt.Println("\nSleep timer cancelled.")
+			case <-done:
+			}
+		}()
+		err := runSleepTimer(tctx, t, remaining, poll, fade)
+		clearSleepTimer(t)
+		return err
+	}
+	go func() {
+		err := runSleepTimer(tctx, t, remaining, poll, fade)
+		clearSleepTimer(t)
+		// Nothing is left to return the error to, so print it
+		if err != nil {
+			fmt.Println("\nERROR: Sleep timer:", err)
+		}
+	}()
+	return nil
+}
+
+// sleepCancelAction is called with spotcon> sleep cancel
+// Stops the running sleep timer
+func sleepCancelAction(c *cli.Context) error {
+	if !stopSleepTimer() {
+		fmt.Println("No sleep timer running.")
+		return nil
+	}
+	fmt.Println("Sleep timer cancelled.")
+	return nil
+}
+
+// displaySleepTimer prints when the running sleep timer goes off
+func displaySleepTimer() {
+	sleepLock.Lock()
+	defer sleepLock.Unlock()
+	if sleeping == nil {
+		fmt.Println("No sleep timer running.")
+		return
+	}
+	if sleeping.Until.IsZero() {
+		fmt.Println("Sleep timer: pausing", sleeping.When)
+		return
+	}
+	d := time.Until(sleeping.Until).Round(time.Second)
+	fmt.Printf("Sleep timer: pausing %s (in %v)\n", sleeping.When, d)
+}
+
+// fadeVolume changes the volume of the active device from one percent to
+// another in steps spread evenly over a duration
+// Returns errStopped if stop is closed before the fade finishes
+func fadeVolume(ctx context.Context, from, to int, over time.Duration, stop <-chan struct{}) error {
+	client := newClient(ctx)
+	d := to - from
+	if d < 0 {
+		d = -d
+	}
+	n := int(over / fadeStep)
+	if n > d {
+		n = d
+	}
+	if n < 1 {
+		n = 1
+	}
+	wait := over / time.Duration(n)
+	for k := 1; k <= n; k++ {
+		select {
+		case <-stop:
+			return errStopped
+		case <-time.After(wait):
+		}
+		err := client.Volume(from + (to-from)*k/n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getAlbumRemaining returns a function that reports the time left on the
+// album of t, whose tracks are tr, or false once the album is no longer
+// playing
+func getAlbumRemaining(ctx context.Context, t *spotify.FullTrack, tr []spotify.SimpleTrack) func() (time.Duration, bool, error) {
+	return func() (time.Duration, bool, error) {
+		item, err := fetchPlayingItem(ctx)
+		if err != nil || item == nil || item.Track == nil || item.Track.Album.ID != t.Album.ID {
+			return 0, false, err
+		}
+		r := item.Duration - item.Progress
+		after := false
+		for _, v := range tr {
+			if after {
+				r += v.Duration
+			}
+			after = after || v.ID == item.Track.ID
+		}
+		return time.Duration(r) * time.Millisecond, true, nil
+	}
+}
+
+// getTrackRemaining returns a function that reports the time left on the
+// track or episode u, or false once it is no longer playing
+func getTrackRemaining(ctx context.Context, u spotify.URI) func() (time.Duration, bool, error) {
+	return func() (time.Duration, bool, error) {
+		item, err := fetchPlayingItem(ctx)
+		if err != nil || item == nil || item.URI != u {
+			return 0, false, err
+		}
+		return time.Duration(item.Duration-item.Progress) * time.Millisecond, true, nil
+	}
+}
+
+// runSleepTimer waits until remaining reports that less than fade is left,
+// checking again every poll if poll is set, then fades out and pauses
+// playback. The volume is put back afterwards so the next play isn't silent
+// Errors are returned rather than passed to checkErr, as the timer usually
+// runs in the background
+func runSleepTimer(ctx context.Context, t *sleepTimer, remaining func() (time.Duration, bool, error), poll, fade time.Duration) error {
+	r, ok, err := remaining()
+	for err == nil && ok && r > fade {
+		wait := r - fade
+		if poll > 0 && wait > poll {
+			wait = poll
+		}
+		select {
+		case <-t.stop:
+			return nil
+		case <-time.After(wait):
+		}
+		r, ok, err = remaining()
+	}
+	if err != nil {
+		return err
+	}
+	if r < 0 || !ok {
+		r = 0
+	}
+	client := newClient(ctx)
+	state, err := client.PlayerState()
+	if err != nil {
+		return err
+	}
+	v := state.Device.Volume
+	if v > 0 && r > 0 {
+		err = fadeVolume(ctx, v, 0, r, t.stop)
+		if err == errStopped {
+			return client.Volume(v)
+		}
+		if err != nil {
+			return fmt.Errorf("could not fade out, %v", err)
+		}
+	}
+	err = client.Pause()
+	if err != nil {
+		return fmt.Errorf("could not pause playback, %v", err)
+	}
+	if v > 0 {
+		err = client.Volume(v)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Println("\nSleep timer: playback paused.")
+	return nil
+}
+
+// clearSleepTimer forgets t once it has finished
+func clearSleepTimer(t *sleepTimer) {
+	sleepLock.Lock()
+	defer sleepLock.Unlock()
+	if sleeping == t {
+		sleeping = nil
+	}
+}
+
+// stopSleepTimer stops the running sleep timer
+// Returns false if no timer was running
+func stopSleepTimer() bool {
+	sleepLock.Lock()
+	defer sleepLock.Unlock()
+	if sleeping == nil {
+		return false
+	}
+	close(sleeping.stop)
+	sleeping = nil
+	return true
+}
@@ -1,14 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"sort"
-	"strings"
 	"time"
-	"unicode"
 
 	"github.com/bobappleyard/readline"
 	"github.com/urfave/cli"
@@ -23,6 +23,10 @@ const (
 	longTrackTemplate = `Track:  {{.Name}}
 Artist:	{{range $index, $artist := .Artists}}{{if $index}}, {{end}}{{.Name}}{{end}}
 Album:	{{.Album.Name}}
+`
+	episodeTemplate = `Show:	{{.Show.Name}}
+Episode:	{{.Name}}
+Published:	{{.ReleaseDate}}
 `
 	shortTrackTemplate = `"{{.Name}}" by {{range $index, $artist := .Artists}}{{if $index}}, {{end}}{{.Name}}{{end}}
 `
@@ -82,19 +86,24 @@ var (
 		spotify.ScopeUserReadPlaybackState,
 		spotify.ScopeUserModifyPlaybackState,
 		spotify.ScopeUserLibraryRead,
+		spotify.ScopeUserReadRecentlyPlayed,
+		spotify.ScopeUserTopRead,
+		"user-read-playback-position", // Where episodes were left off
 	)
 	state = "Spotcon"
 	ch    = make(chan *spotify.Client)
 	tok   *oauth2.Token
 )
 
-func init() {
+// login authorizes spotcon with the token saved in ~/.spotcon, or asks
+// the user to log in if there isn't one
+func login() {
 	err := loadToken()
 	if err != nil {
 		startAuth()
 	} else {
 		// Create new client from the loaded token
-		client := auth.NewClient(tok)
+		client := newClient(context.Background())
 		// Save new token
 		err = saveToken(tok)
 		checkErr(err)
@@ -120,6 +129,60 @@ func main() {
 	app.Usage = "Control Spotify Connect enabled devices via terminal."
 	app.UsageText = "spotcon> command [subcommand] [--flags] [arguments...]"
 
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "Run the spotcon commands found in `FILE`",
+		},
+		cli.BoolFlag{
+			Name:  "keep-going, k",
+			Usage: "Continue running FILE after a command fails",
+		},
+		cli.BoolFlag{
+			Name:  "debug",
+			Usage: "Print each request to Spotify and its response",
+		},
+		cli.StringFlag{
+			Name:  "record",
+			Usage: "Save each request to Spotify and its response in `DIR`",
+		},
+		cli.StringFlag{
+			Name:  "replay",
+			Usage: "Answer requests with the responses saved in `DIR` instead of asking Spotify",
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		if tok != nil {
+			return nil // Set up by an earlier command
+		}
+		debug = c.Bool("debug")
+		setTransport(c.String("record"), c.String("replay"))
+		if c.IsSet("replay") {
+			// Replayed requests don't need to be authorized
+			tok = &oauth2.Token{AccessToken: "replay"}
+			return nil
+		}
+		login()
+		return nil
+	}
+	// Errors are returned to runArgs, never exit, so a typo in a script or
+	// at the prompt doesn't end spotcon
+	app.ExitErrHandler = func(c *cli.Context, err error) {}
+	prompt := false
+	app.Action = func(c *cli.Context) error {
+		if c.IsSet("file") {
+			return runScript(getContext(c), c.App, c.String("file"), c.Bool("keep-going"))
+		}
+		if c.Args().Present() {
+			return fmt.Errorf("unknown command %q", c.Args().First())
+		}
+		if !interactive {
+			prompt = true
+			return nil
+		}
+		return cli.ShowAppHelp(c)
+	}
+
 	cli.AppHelpTemplate = appHelpTemplate
 	cli.CommandHelpTemplate = commandHelpTemplate
 	cli.SubcommandHelpTemplate = subcommandHelpTemplate
@@ -130,8 +193,14 @@ func main() {
 			Aliases: []string{"clc"},
 			Usage:   "Clear the command window",
 			Action: func(c *cli.Context) error {
-				clearAction(c)
-				return nil
+				return clearAction(c)
+			},
+		},
+		{
+			Name:  "daemon",
+			Usage: "Run scheduled jobs until stopped",
+			Action: func(c *cli.Context) error {
+				return daemonAction(c)
 			},
 		},
 		{
@@ -140,8 +209,53 @@ func main() {
 			Usage:     "List available devices",
 			ArgsUsage: "",
 			Action: func(c *cli.Context) error {
-				devicesAction(c)
-				return nil
+				return devicesAction(c)
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "alias",
+					Usage:     "Save ALIAS as a name for DEVICE, or list aliases",
+					ArgsUsage: "[ALIAS DEVICE]",
+					Action: func(c *cli.Context) error {
+						return aliasAction(c)
+					},
+				},
+				{
+					Name:      "move",
+					Usage:     "Move playback to DEVICE and keep playing",
+					ArgsUsage: "DEVICE",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "keep-volume",
+							Usage: "Set DEVICE to the volume of the current device",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return moveAction(c)
+					},
+				},
+				{
+					Name:      "unalias",
+					Usage:     "Remove the device alias ALIAS",
+					ArgsUsage: "ALIAS",
+					Action: func(c *cli.Context) error {
+						return unaliasAction(c)
+					},
+				},
+				{
+					Name:      "default",
+					Usage:     "Play on DEVICE when no devices are active",
+					ArgsUsage: "[DEVICE]",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "clear",
+							Usage: "Remove the default device",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return defaultDeviceAction(c)
+					},
+				},
 			},
 		},
 		{
@@ -149,8 +263,75 @@ func main() {
 			Aliases: []string{"l"},
 			Usage:   "Display \"Your Music\"",
 			Action: func(c *cli.Context) error {
-				libAction(c)
-				return nil
+				return libAction(c, "")
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "tracks",
+					Usage: "Display saved tracks",
+					Action: func(c *cli.Context) error {
+						return libAction(c, track)
+					},
+				},
+				{
+					Name:  "albums",
+					Usage: "Display saved albums",
+					Action: func(c *cli.Context) error {
+						return libAction(c, album)
+					},
+				},
+				{
+					Name:  "playlists",
+					Usage: "Display saved playlists",
+					Action: func(c *cli.Context) error {
+						return libAction(c, plist)
+					},
+				},
+				{
+					Name:  "shows",
+					Usage: "Display saved podcasts",
+					Action: func(c *cli.Context) error {
+						return libAction(c, show)
+					},
+				},
+			},
+		},
+		{
+			Name:      "mark",
+			Usage:     "Options for saving named positions in the current track",
+			ArgsUsage: "[arguments...]",
+			Subcommands: []cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Save the current position as NAME",
+					ArgsUsage: "NAME",
+					Action: func(c *cli.Context) error {
+						return markAddAction(c)
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List the positions saved for the current track",
+					Action: func(c *cli.Context) error {
+						return markListAction(c)
+					},
+				},
+				{
+					Name:      "go",
+					Usage:     "Seek to the position saved as NAME",
+					ArgsUsage: "NAME",
+					Action: func(c *cli.Context) error {
+						return markGoAction(c)
+					},
+				},
+				{
+					Name:      "rm",
+					Usage:     "Remove the position saved as NAME",
+					ArgsUsage: "NAME",
+					Action: func(c *cli.Context) error {
+						return markRmAction(c)
+					},
+				},
 			},
 		},
 		{
@@ -158,17 +339,26 @@ func main() {
 			Aliases: []string{"n"},
 			Usage:   "Skip to the next track in queue",
 			Action: func(c *cli.Context) error {
-				skipAction(c, true)
-				return nil
+				return skipAction(c, true)
 			},
 		},
 		{
 			Name:    "now",
 			Aliases: []string{"np"},
 			Usage:   "Display information about \"Now Playing\"",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "watch, w",
+					Usage: "Keep updating until Ctrl-C is pressed",
+				},
+				cli.DurationFlag{
+					Name:  "interval",
+					Value: 2 * time.Second,
+					Usage: "Update every `DURATION` with --watch",
+				},
+			},
 			Action: func(c *cli.Context) error {
-				nowAction(c)
-				return nil
+				return nowAction(c)
 			},
 		},
 		{
@@ -177,7 +367,7 @@ func main() {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "repeat, r",
-					Usage: "Set playback option repeat [on, off]",
+					Usage: "Set playback option repeat [track, context, off]",
 				},
 				cli.StringFlag{
 					Name:  "shuffle, s",
@@ -186,8 +376,17 @@ func main() {
 			},
 			Usage: "Options for changing current playback parameters",
 			Action: func(c *cli.Context) error {
-				optAction(c)
-				return nil
+				return optAction(c)
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "repeat",
+					Usage:     "Set repeat, or cycle through off, context and track",
+					ArgsUsage: "[track | context | off]",
+					Action: func(c *cli.Context) error {
+						return optRepeatAction(c)
+					},
+				},
 			},
 		},
 		{
@@ -195,8 +394,7 @@ func main() {
 			Aliases: []string{"pp"},
 			Usage:   "Pause playback",
 			Action: func(c *cli.Context) error {
-				pauseAction(c)
-				return nil
+				return pauseAction(c)
 			},
 		},
 		{
@@ -209,7 +407,15 @@ func main() {
 				},
 				cli.StringFlag{
 					Name:  "track, tr",
-					Usage: "Play track with specified `'NAME'` or number from search results",
+					Usage: "Play track with specified `'NAME'` or numbers from search results (1,3,5-7)",
+				},
+				cli.StringFlag{
+					Name:  "tracks-from",
+					Usage: "Play the track URIs or links listed in `FILE`",
+				},
+				cli.BoolFlag{
+					Name:  "all-results",
+					Usage: "Play every track from the last search results",
 				},
 				cli.StringFlag{
 					Name:  "album, al",
@@ -223,11 +429,28 @@ func main() {
 					Name:  "plist, pl",
 					Usage: "Play playlist with specified `'NAME'` or number from search results",
 				},
+				cli.StringFlag{
+					Name:  "mode",
+					Usage: "Play an artist's (top) tracks, (discography) in release order, or (radio) recommendations",
+				},
+				cli.StringFlag{
+					Name:  "at",
+					Usage: "Start an album or playlist at track `NUMBER` or 'NAME'",
+				},
+				cli.StringFlag{
+					Name:  "position",
+					Usage: "Start playback at `TIME` (m:ss) into the first track",
+				},
+				cli.StringFlag{
+					Name:  "from",
+					Value: "search",
+					Usage: "Play numbers from the last `LISTING` shown, one of (search, lib)",
+				},
 			},
-			Usage: "Start/Resume playback",
+			Usage:     "Start/Resume playback",
+			ArgsUsage: "[NUMBER | lib:NUMBER | URI | URL]",
 			Action: func(c *cli.Context) error {
-				playAction(c)
-				return nil
+				return playAction(c)
 			},
 		},
 		{
@@ -235,8 +458,7 @@ func main() {
 			Aliases: []string{"pr"},
 			Usage:   "Skip to the previous track in queue",
 			Action: func(c *cli.Context) error {
-				skipAction(c, false)
-				return nil
+				return skipAction(c, false)
 			},
 		},
 		{
@@ -244,14 +466,148 @@ func main() {
 			Aliases: []string{"q"},
 			Usage:   "Quit application",
 			Action: func(c *cli.Context) error {
-				quitAction(c)
-				return nil
+				return quitAction(c)
+			},
+		},
+		{
+			Name:  "recent",
+			Usage: "Display recently played tracks",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "limit, n",
+					Value: 20,
+					Usage: "Show the last `N` tracks played",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return recentAction(c)
 			},
 		},
 		{
-			Name:    "search",
-			Aliases: []string{"s"},
+			Name:    "recommend",
+			Aliases: []string{"rec"},
+			Usage:   "Recommend tracks like \"Now Playing\"",
 			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "limit, n",
+					Value: 20,
+					Usage: "Show `N` recommended tracks",
+				},
+				cli.StringFlag{
+					Name:  "genre",
+					Usage: "Also recommend from `GENRES` (e.g. rock,blues)",
+				},
+				cli.StringFlag{
+					Name:  "acousticness",
+					Usage: "Target acousticness `0.0-1.0` or a range (0.2-0.5)",
+				},
+				cli.StringFlag{
+					Name:  "danceability",
+					Usage: "Target danceability `0.0-1.0` or a range (0.2-0.5)",
+				},
+				cli.StringFlag{
+					Name:  "energy",
+					Usage: "Target energy `0.0-1.0` or a range (0.2-0.5)",
+				},
+				cli.StringFlag{
+					Name:  "tempo",
+					Usage: "Target tempo in `BPM` or a range (120-130)",
+				},
+				cli.StringFlag{
+					Name:  "valence",
+					Usage: "Target valence (positivity) `0.0-1.0` or a range (0.2-0.5)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return recommendAction(c)
+			},
+		},
+		{
+			Name:  "schedule",
+			Usage: "Options for running commands at set times",
+			Action: func(c *cli.Context) error {
+				return scheduleListAction(c)
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Run each COMMAND at a time of day",
+					ArgsUsage: "COMMAND...",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "at",
+							Usage: "Run at `TIME` of day (e.g. 07:00)",
+						},
+						cli.StringFlag{
+							Name:  "days",
+							Value: "daily",
+							Usage: "Run on (daily), (weekdays), (weekends), or `DAYS` such as mon,wed,fri",
+						},
+						cli.StringFlag{
+							Name:  "missed",
+							Value: "skip",
+							Usage: "Missed runs are (skip)ped, (run) late, or run if no later than `DURATION`",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return scheduleAddAction(c)
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List scheduled jobs",
+					Action: func(c *cli.Context) error {
+						return scheduleListAction(c)
+					},
+				},
+				{
+					Name:      "rm",
+					Usage:     "Remove the job numbered ID",
+					ArgsUsage: "ID",
+					Action: func(c *cli.Context) error {
+						return scheduleRmAction(c)
+					},
+				},
+			},
+		},
+		{
+			Name:      "search",
+			Aliases:   []string{"s"},
+			ArgsUsage: "QUERY | more | page NUMBER",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "limit, n",
+					Value: 5,
+					Usage: "Show `N` results of each type per page",
+				},
+				cli.StringFlag{
+					Name:  "artist-is",
+					Usage: "Only match items by the artist `'NAME'`",
+				},
+				cli.StringFlag{
+					Name:  "album-is",
+					Usage: "Only match items from the album `'NAME'`",
+				},
+				cli.StringFlag{
+					Name:  "genre",
+					Usage: "Only match artists and tracks in `GENRE`",
+				},
+				cli.StringFlag{
+					Name:  "year",
+					Usage: "Only match items released in `YEAR` or a range of years (1990-1999)",
+				},
+				cli.BoolFlag{
+					Name:  "new",
+					Usage: "Only match albums released in the past two weeks",
+				},
+				cli.BoolFlag{
+					Name:  "hipster",
+					Usage: "Only match albums with the lowest 10% popularity",
+				},
+				cli.StringFlag{
+					Name:  "market, m",
+					Usage: "Only match items available in the country `CODE` (e.g. US, GB)",
+				},
 				cli.BoolFlag{
 					Name:  "artist, ar",
 					Usage: "Show search results for artists",
@@ -268,11 +624,18 @@ func main() {
 					Name:  "playlist, pl",
 					Usage: "Show search results for playlists",
 				},
+				cli.BoolFlag{
+					Name:  "show, sh",
+					Usage: "Show search results for podcasts",
+				},
+				cli.BoolFlag{
+					Name:  "episode, ep",
+					Usage: "Show search results for podcast episodes",
+				},
 			},
 			Usage: "Search for artists, albums, tracks, or playlists",
 			Action: func(c *cli.Context) error {
-				searchAction(c)
-				return nil
+				return searchAction(c)
 			},
 		},
 		{
@@ -284,16 +647,116 @@ func main() {
 					Name:  "ff",
 					Usage: "Fast forward playback by SECONDS or 15 seconds if not specified",
 					Action: func(c *cli.Context) error {
-						seekAction(c, true)
-						return nil
+						return seekAction(c, true)
 					},
 				},
 				{
 					Name:  "rw",
 					Usage: "Rewind playback by SECONDS or 15 seconds if not specified",
 					Action: func(c *cli.Context) error {
-						seekAction(c, false)
-						return nil
+						return seekAction(c, false)
+					},
+				},
+				{
+					Name:      "to",
+					Usage:     "Seek to POSITION (m:ss, h:mm:ss) or PERCENT (50%) of the track",
+					ArgsUsage: "POSITION",
+					Action: func(c *cli.Context) error {
+						return seekToAction(c, false)
+					},
+				},
+				{
+					Name:  "restart",
+					Usage: "Seek to the beginning of the track",
+					Action: func(c *cli.Context) error {
+						return seekToAction(c, true)
+					},
+				},
+			},
+		},
+		{
+			Name:      "sleep",
+			Usage:     "Fade out and pause playback after DURATION or at the end of the track or album",
+			ArgsUsage: "[DURATION]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "end",
+					Usage: "Pause at the end of the current (track) or (album)",
+				},
+				cli.StringFlag{
+					Name:  "fade",
+					Value: "30s",
+					Usage: "Fade out over `DURATION` before pausing",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return sleepAction(c)
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "cancel",
+					Usage: "Stop the sleep timer",
+					Action: func(c *cli.Context) error {
+						return sleepCancelAction(c)
+					},
+				},
+			},
+		},
+		{
+			Name:      "source",
+			Usage:     "Run the spotcon commands found in FILE",
+			ArgsUsage: "FILE",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "keep-going, k",
+					Usage: "Continue running FILE after a command fails",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return sourceAction(c)
+			},
+		},
+		{
+			Name:      "top",
+			Usage:     "Display your top tracks or artists",
+			ArgsUsage: "[arguments...]",
+			Subcommands: []cli.Command{
+				{
+					Name:  "tracks",
+					Usage: "Display your top tracks",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "range, r",
+							Value: "medium",
+							Usage: "Time `RANGE` of (short) 4 weeks, (medium) 6 months, or (long) several years",
+						},
+						cli.IntFlag{
+							Name:  "limit, n",
+							Value: 20,
+							Usage: "Show `N` tracks",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return topAction(c, track)
+					},
+				},
+				{
+					Name:  "artists",
+					Usage: "Display your top artists",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "range, r",
+							Value: "medium",
+							Usage: "Time `RANGE` of (short) 4 weeks, (medium) 6 months, or (long) several years",
+						},
+						cli.IntFlag{
+							Name:  "limit, n",
+							Value: 20,
+							Usage: "Show `N` artists",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return topAction(c, artist)
 					},
 				},
 			},
@@ -308,24 +771,68 @@ func main() {
 					Name:  "up",
 					Usage: "Increase volume by PERCENT or 10% if not specified",
 					Action: func(c *cli.Context) error {
-						volAdjustAction(c, true)
-						return nil
+						return volAdjustAction(c, true)
 					},
 				},
 				{
 					Name:  "down",
 					Usage: "Decrease volume by PERCENT or 10% if not specified",
 					Action: func(c *cli.Context) error {
-						volAdjustAction(c, false)
-						return nil
+						return volAdjustAction(c, false)
 					},
 				},
 				{
 					Name:  "set",
 					Usage: "Set volume to PERCENT",
 					Action: func(c *cli.Context) error {
-						volSetAction(c)
-						return nil
+						return volSetAction(c)
+					},
+				},
+				{
+					Name:      "fade",
+					Usage:     "Gradually change volume to PERCENT",
+					ArgsUsage: "PERCENT",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "over",
+							Value: "10s",
+							Usage: "Spread the change over `DURATION`",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return volFadeAction(c)
+					},
+				},
+				{
+					Name:      "preset",
+					Usage:     "Set volume to preset NAME, save NAME as PERCENT, or list presets",
+					ArgsUsage: "[NAME [PERCENT]]",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "rm",
+							Usage: "Remove preset NAME",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return volPresetAction(c)
+					},
+				},
+				{
+					Name:      "max",
+					Usage:     "Limit the volume of a device to PERCENT, or show its limit",
+					ArgsUsage: "[PERCENT]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "device, d",
+							Usage: "Limit the volume of `DEVICE` instead of the active device",
+						},
+						cli.BoolFlag{
+							Name:  "clear",
+							Usage: "Remove the volume limit",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						return volMaxAction(c)
 					},
 				},
 			},
@@ -335,23 +842,19 @@ func main() {
 	sort.Sort(cli.FlagsByName(app.Flags))
 	sort.Sort(cli.CommandsByName(app.Commands))
 
-	lastQuote := rune(0)
-	f := func(c rune) bool {
-		switch {
-		case c == lastQuote:
-			lastQuote = rune(0)
-			return false
-		case lastQuote != rune(0):
-			return false
-		case unicode.In(c, unicode.Quotation_Mark):
-			lastQuote = c
-			return false
-		default:
-			return unicode.IsSpace(c)
-
-		}
+	err := runArgs(app, os.Args)
+	if err == errUsage {
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal("ERROR: ", err)
+	}
+	// Read commands from the prompt unless a command or script was given
+	if !prompt {
+		return
 	}
 
+	interactive = true
 	for {
 		line, err := readline.String("\nspotcon> ")
 		if err == io.EOF {
@@ -362,36 +865,55 @@ func main() {
 			break
 		}
 		readline.AddHistory(line)
-		c := strings.FieldsFunc("spotcon "+line, f)
-		err = app.Run(c)
-		checkErr(err)
+		err = runCommand(app, line)
+		if err != nil && err != errUsage {
+			fmt.Println("ERROR:", err)
+		}
 	}
-
-	err := app.Run(os.Args)
-	checkErr(err)
 }
 
-func quitAction(c *cli.Context) {
+func quitAction(c *cli.Context) error {
 	if c.Args().Present() {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
 	os.Exit(0)
+	return nil
 }
 
-func clearAction(c *cli.Context) {
+func clearAction(c *cli.Context) error {
 	if c.Args().Present() {
-		err := cli.ShowCommandHelp(c, c.Command.Name)
-		checkErr(err)
-		return
+		return showUsage(c)
 	}
 	_, err := os.Stdout.WriteString("\x1b[3;J\x1b[H\x1b[2J")
 	checkErr(err)
+	return nil
 }
 
+// errUsage is returned by a command after printing its help
+var errUsage = errors.New("incorrect usage")
+
+// commandError carries an error from checkErr up to runArgs
+type commandError struct {
+	err error
+}
+
+// checkErr stops the running command if err is not nil
+// The error is returned by runArgs
 func checkErr(err error) {
+	if isCancelled(err) {
+		panic(errInterrupted)
+	}
+	if err != nil {
+		panic(commandError{err})
+	}
+}
+
+// showUsage prints the help of the running command
+// Returns errUsage, as the help already explains what went wrong
+func showUsage(c *cli.Context) error {
+	err := cli.ShowCommandHelp(c, c.Command.Name)
 	if err != nil {
-		log.Fatal("ERROR:", err)
+		return err
 	}
+	return errUsage
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"os/user"
+)
+
+// loadData reads v from the file name in ~/.spotcon
+// Returns an error satisfying os.IsNotExist if nothing has been saved
+func loadData(name string, v interface{}) error {
+	usr, err := user.Current()
+	checkErr(err)
+	file, err := os.Open(usr.HomeDir + tokenDir + "/" + name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return gob.NewDecoder(file).Decode(v)
+}
+
+// saveData stores v in the file name in ~/.spotcon
+// v is written to a temporary file which then replaces name, so a daemon
+// reading name at the same time never sees a partly written file
+func saveData(name string, v interface{}) error {
+	usr, err := user.Current()
+	checkErr(err)
+	if _, err = os.Stat(usr.HomeDir + tokenDir); os.IsNotExist(err) {
+		err = os.Mkdir(usr.HomeDir+tokenDir, 0700)
+		checkErr(err)
+	}
+	path := usr.HomeDir + tokenDir + "/" + name
+	file, err := ioutil.TempFile(usr.HomeDir+tokenDir, name+".*")
+	if err != nil {
+		return err
+	}
+	err = gob.NewEncoder(file).Encode(v)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
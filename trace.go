@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDebugBody is the most of a request or response body printed by --debug
+const maxDebugBody = 2000
+
+// unsafeName matches characters that are left out of fixture file names
+var unsafeName = regexp.MustCompile(`[^0-9A-Za-z]+`)
+
+// exchange is a request to the Web API and its response, saved by --record
+// and served back by --replay
+type exchange struct {
+	Method      string
+	URL         string
+	RequestBody string `json:",omitempty"`
+	Status      int
+	Header      http.Header
+	Body        string
+}
+
+// traceTransport prints each request and response when debug is set and
+// saves them as fixtures in Record if it is set
+// The Authorization header is never printed or saved
+type traceTransport struct {
+	Base   http.RoundTripper
+	Record string // Directory to save exchanges in
+
+	mu sync.Mutex
+	n  int // Number of the next fixture
+}
+
+// replayTransport answers requests with the exchanges saved in Dir instead
+// of sending them to Spotify
+// Exchanges with the same method and URL are served in the order they were
+// saved, repeating the last one once they run out
+type replayTransport struct {
+	Dir string
+
+	once      sync.Once
+	err       error
+	mu        sync.Mutex
+	exchanges map[string][]exchange
+}
+
+// RoundTrip sends req with Base, printing and recording the exchange
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !debug && t.Record == "" {
+		return t.Base.RoundTrip(req)
+	}
+	x := exchange{Method: req.Method, URL: req.URL.String()}
+	if req.GetBody != nil {
+		b, err := req.GetBody()
+		if err == nil {
+			body, _ := ioutil.ReadAll(b)
+			x.RequestBody = string(body)
+		}
+	}
+	debugf("--> %s %s", x.Method, x.URL)
+	for k, v := range req.Header {
+		if k == "Authorization" {
+			v = []string{"[REDACTED]"}
+		}
+		debugf("    %s: %s", k, strings.Join(v, ", "))
+	}
+	if x.RequestBody != "" {
+		debugf("    %s", getDebugBody(x.RequestBody))
+	}
+
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		debugf("<-- %s %s: %v", x.Method, x.URL, err)
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	x.Status, x.Header, x.Body = resp.StatusCode, resp.Header, string(body)
+	debugf("<-- %s (%v)", resp.Status, time.Since(start).Round(time.Millisecond))
+	if x.Body != "" {
+		debugf("    %s", getDebugBody(x.Body))
+	}
+	if t.Record != "" {
+		if err := t.save(x); err != nil {
+			fmt.Fprintln(os.Stderr, "ERROR: Could not record request,", err)
+		}
+	}
+	return resp, nil
+}
+
+// save writes x to the next fixture file in t.Record
+func (t *traceTransport) save(x exchange) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.n == 0 {
+		// Number after the last fixture already recorded, so none are
+		// overwritten even if some were deleted
+		if err := os.MkdirAll(t.Record, 0700); err != nil {
+			return err
+		}
+		f, err := filepath.Glob(filepath.Join(t.Record, "*.json"))
+		if err != nil {
+			return err
+		}
+		for _, v := range f {
+			n, err := strconv.Atoi(strings.SplitN(filepath.Base(v), "-", 2)[0])
+			if err == nil && n > t.n {
+				t.n = n
+			}
+		}
+		t.n++
+	}
+	b, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(x.URL)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%04d-%s-%s.json", t.n, x.Method, unsafeName.ReplaceAllString(strings.Trim(u.Path, "/"), "-"))
+	t.n++
+	return ioutil.WriteFile(filepath.Join(t.Record, name), b, 0600)
+}
+
+// RoundTrip answers req with the next exchange saved for its method and URL
+// Requests that were never recorded get a 404 response
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(t.load)
+	if t.err != nil {
+		return nil, t.err
+	}
+	key := req.Method + " " + req.URL.String()
+	t.mu.Lock()
+	e := t.exchanges[key]
+	var x exchange
+	if len(e) == 0 {
+		x.Status = http.StatusNotFound
+		x.Body = fmt.Sprintf(`{"error":{"status":404,"message":"no recorded response for %s"}}`, key)
+	} else {
+		x = e[0]
+		if len(e) > 1 {
+			t.exchanges[key] = e[1:]
+		}
+	}
+	t.mu.Unlock()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", x.Status, http.StatusText(x.Status)),
+		StatusCode:    x.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        x.Header,
+		Body:          ioutil.NopCloser(strings.NewReader(x.Body)),
+		ContentLength: int64(len(x.Body)),
+		Request:       req,
+	}, nil
+}
+
+// load reads the exchanges saved in t.Dir
+func (t *replayTransport) load() {
+	t.exchanges = map[string][]exchange{}
+	f, err := filepath.Glob(filepath.Join(t.Dir, "*.json"))
+	if err == nil && len(f) == 0 {
+		err = fmt.Errorf("no recorded requests found in %s", t.Dir)
+	}
+	if err != nil {
+		t.err = err
+		return
+	}
+	// Glob returns names in order, which is the order they were recorded
+	for _, v := range f {
+		b, err := ioutil.ReadFile(v)
+		if err != nil {
+			t.err = err
+			return
+		}
+		var x exchange
+		if err := json.Unmarshal(b, &x); err != nil {
+			t.err = fmt.Errorf("%s: %v", v, err)
+			return
+		}
+		key := x.Method + " " + x.URL
+		t.exchanges[key] = append(t.exchanges[key], x)
+	}
+}
+
+// getDebugBody returns s shortened to maxDebugBody for printing
+func getDebugBody(s string) string {
+	if len(s) <= maxDebugBody {
+		return s
+	}
+	return fmt.Sprintf("%s... (%d bytes)", s[:maxDebugBody], len(s))
+}
+
+// setTransport sets up transport to record exchanges in the directory
+// record, or replay the exchanges in the directory replay, if either is set
+func setTransport(record, replay string) {
+	var base http.RoundTripper = http.DefaultTransport
+	if replay != "" {
+		base = &replayTransport{Dir: replay}
+	}
+	transport = &retryTransport{Base: &traceTransport{Base: base, Record: record}}
+}
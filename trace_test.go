@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// replay answers requests with the fixtures in testdata/dir until the
+// returned function is called
+func replay(dir string) func() {
+	old := tok
+	tok = &oauth2.Token{AccessToken: "replay"}
+	setTransport("", filepath.Join("testdata", dir))
+	return func() {
+		tok = old
+		setTransport("", "")
+	}
+}
+
+func TestGetPlayingItem(t *testing.T) {
+	defer replay("playing")()
+	tests := []struct {
+		name     string
+		uri      string
+		duration int
+		progress int
+		playing  bool
+		isTrack  bool
+	}{
+		{"Blackbird", "spotify:track:6rqhFgbbKwnb9MLmUQDhG6", 478000, 61000, true, true},
+		{"Episode 1", "spotify:episode:512ojhOuo1ktJprKbVcKyQ", 1800000, 5000, false, false},
+	}
+	for _, tt := range tests {
+		i := getPlayingItem(context.Background())
+		if i == nil {
+			t.Fatalf("getPlayingItem() = nil, want %s", tt.name)
+		}
+		if i.Name != tt.name || string(i.URI) != tt.uri || i.Duration != tt.duration ||
+			i.Progress != tt.progress || i.Playing != tt.playing || (i.Track != nil) != tt.isTrack {
+			t.Errorf("getPlayingItem() = %+v, want %s", i, tt.name)
+		}
+	}
+	if i := getPlayingItem(context.Background()); i != nil {
+		t.Errorf("getPlayingItem() = %+v with nothing playing, want nil", i)
+	}
+}
+
+func TestRecordNumbering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spotcon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// 0002 was deleted, so the next fixture must not reuse 0003
+	for _, v := range []string{"0001-GET-v1-me.json", "0003-GET-v1-me.json"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, v), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tr := &traceTransport{Record: dir}
+	for _, v := range []string{"me/player", "me/player/devices"} {
+		err := tr.save(exchange{Method: http.MethodGet, URL: apiURL + v, Status: http.StatusOK})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, v := range []string{"0004-GET-v1-me-player.json", "0005-GET-v1-me-player-devices.json"} {
+		if _, err := os.Stat(filepath.Join(dir, v)); err != nil {
+			t.Error(err)
+		}
+	}
+}
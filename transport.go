@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/zmb3/spotify"
+	"golang.org/x/oauth2"
+)
+
+const (
+	maxRetries     = 4                      // Most times a request is retried
+	requestTimeout = 15 * time.Second       // Longest wait for each attempt at a request
+	retryBackoff   = 500 * time.Millisecond // Wait before the first retry, doubled for each retry after
+	maxRetryAfter  = time.Minute            // Longest Retry-After waited for before giving up
+)
+
+// debug is true when spotcon is run with --debug
+var debug bool
+
+// transport sends every request to the Web API
+var transport http.RoundTripper = &retryTransport{Base: &traceTransport{Base: http.DefaultTransport}}
+
+// retryTransport retries requests that fail because of rate limiting or
+// a temporary problem with the Web API
+//   - 429 responses are retried after the time in their Retry-After header,
+//     unless it is longer than maxRetryAfter
+//   - 5xx responses and network errors are retried with jittered backoff,
+//     but only for idempotent methods so a skip is never sent twice
+//   - each attempt is limited to requestTimeout
+type retryTransport struct {
+	Base http.RoundTripper
+}
+
+// cancelBody releases the timeout of a request when its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases its timeout
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// RoundTrip sends req, retrying it up to maxRetries times
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for n := 0; ; n++ {
+		ctx, cancel := context.WithTimeout(req.Context(), requestTimeout)
+		r := req.WithContext(ctx)
+		if n > 0 && req.GetBody != nil {
+			b, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			r.Body = b
+		}
+		resp, err := t.Base.RoundTrip(r)
+		wait, retry := getRetryWait(req, resp, err, n)
+		if !retry {
+			if n > 0 {
+				debugf("%s %s: sent after %d retries", req.Method, req.URL.Path, n)
+			}
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			resp.Body = &cancelBody{resp.Body, cancel}
+			return resp, nil
+		}
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = resp.Status
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+		if wait > maxRetryAfter {
+			return nil, fmt.Errorf("Spotify is rate limiting requests for %v, try again later", wait)
+		}
+		debugf("%s %s: %s, retry %d of %d in %v", req.Method, req.URL.Path, reason, n+1, maxRetries, wait)
+		if wait >= 5*time.Second && !debug {
+			fmt.Fprintf(os.Stderr, "Spotify is busy, trying again in %v...\n", wait.Round(time.Second))
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// debugf prints a message to stderr when spotcon is run with --debug
+func debugf(format string, a ...interface{}) {
+	if debug {
+		fmt.Fprintf(os.Stderr, "DEBUG: "+format+"\n", a...)
+	}
+}
+
+// getBackoff returns how long to wait before retry n+1
+// The wait doubles with each retry and is jittered so that requests made
+// together are not retried together
+func getBackoff(n int) time.Duration {
+	d := retryBackoff << uint(n)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// getRetryWait returns how long to wait before retrying attempt n at req,
+// or false if it should not be retried
+func getRetryWait(req *http.Request, resp *http.Response, err error, n int) (time.Duration, bool) {
+	if n >= maxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return 0, false // The body can't be sent again
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		// A rate limited request was never handled so any method can be retried
+		if s, e := strconv.Atoi(resp.Header.Get("Retry-After")); e == nil {
+			return time.Duration(s) * time.Second, true
+		}
+		return getBackoff(n), true
+	}
+	switch req.Method {
+	case "GET", "HEAD", "PUT", "DELETE":
+	default:
+		return 0, false
+	}
+	if err != nil || resp.StatusCode >= 500 {
+		return getBackoff(n), true
+	}
+	return 0, false
+}
+
+// newClient returns a Spotify client that sends requests with newHTTPClient()
+func newClient(ctx context.Context) spotify.Client {
+	return spotify.NewClient(newHTTPClient(ctx))
+}
+
+// newHTTPClient returns an HTTP client that authorizes requests with the
+// user's token and sends them through transport with ctx
+func newHTTPClient(ctx context.Context) *http.Client {
+	a := auth.NewClient(tok)
+	return &http.Client{Transport: &oauth2.Transport{Source: &a, Base: contextTransport{transport, ctx}}}
+}
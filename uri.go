@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/zmb3/spotify"
+)
+
+const (
+	episode = "episode"
+	show    = "show"
+)
+
+// spotifyID matches the base-62 ID of a Spotify item
+var spotifyID = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// spotifyLink is an item parsed from a Spotify URI or open.spotify.com link
+type spotifyLink struct {
+	Type     string // One of (artist, album, playlist, track, episode, show)
+	ID       spotify.ID
+	Position int // Position to start playback at in milliseconds
+}
+
+// URI returns the Spotify URI of the item
+func (l spotifyLink) URI() spotify.URI {
+	return spotify.URI("spotify:" + l.Type + ":" + string(l.ID))
+}
+
+// getID returns the ID from a Spotify URI
+func getID(u spotify.URI) spotify.ID {
+	p := strings.Split(string(u), ":")
+	return spotify.ID(p[len(p)-1])
+}
+
+// isLink returns true if s looks like a Spotify URI or open.spotify.com link
+func isLink(s string) bool {
+	return strings.HasPrefix(s, "spotify:") || strings.Contains(s, "open.spotify.com/")
+}
+
+// parseLink parses a Spotify URI or open.spotify.com link
+// Accepts forms such as:
+//   - spotify:track:ID
+//   - spotify:user:USER:playlist:ID
+//   - https://open.spotify.com/album/ID?si=...
+//   - https://open.spotify.com/track/ID#1:30
+//
+// A #m:ss suffix sets the position to start playback at
+func parseLink(s string) (spotifyLink, error) {
+	var l spotifyLink
+	var parts []string
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "#"); i >= 0 {
+		p, err := parsePosition(s[i+1:])
+		if err != nil {
+			return l, err
+		}
+		l.Position = p
+		s = s[:i]
+	}
+	if strings.HasPrefix(s, "spotify:") {
+		parts = strings.Split(strings.TrimPrefix(s, "spotify:"), ":")
+	} else {
+		if !strings.Contains(s, "://") {
+			s = "https://" + s
+		}
+		u, err := url.Parse(s)
+		if err != nil || u.Host != "open.spotify.com" {
+			return l, fmt.Errorf("not a Spotify link: %s", s)
+		}
+		parts = strings.Split(strings.Trim(u.Path, "/"), "/")
+	}
+	// Skip prefixes such as user:USER, embed, and intl-xx
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	if len(parts) != 2 || !spotifyID.MatchString(parts[1]) {
+		return l, fmt.Errorf("not a Spotify link: %s", s)
+	}
+	switch parts[0] {
+	case track, album, artist, plist, episode, show:
+		l.Type = parts[0]
+		l.ID = spotify.ID(parts[1])
+		return l, nil
+	}
+	return l, fmt.Errorf("cannot play a Spotify %s", parts[0])
+}
+
+// playLink begins playback of a Spotify URI or open.spotify.com link
+// A position in the link is used unless st sets one
+func playLink(ctx context.Context, s string, st playStart) error {
+	l, err := parseLink(s)
+	if err != nil {
+		return err
+	}
+	if st.Position == 0 {
+		st.Position = l.Position
+	}
+	return playURI(ctx, l.URI(), l.Type, st)
+}
+
+// readURIs reads the track and episode URIs or links in the file at path,
+// one per line. Blank lines and lines beginning with # are ignored
+func readURIs(path string) ([]spotify.URI, error) {
+	var u []spotify.URI
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	s := bufio.NewScanner(file)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		l, err := parseLink(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		if l.Type != track && l.Type != episode {
+			return nil, fmt.Errorf("%s:%d: can only play tracks and episodes, not a %s", path, n, l.Type)
+		}
+		u = append(u, l.URI())
+	}
+	return u, s.Err()
+}
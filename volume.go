@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/zmb3/spotify"
+)
+
+// volFadeAction is called with spotcon> vol fade
+// Gradually changes volume to PERCENT over the duration set by --over
+func volFadeAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() != 1 {
+		return showUsage(c)
+	}
+	i, err := strconv.Atoi(strings.TrimSuffix(c.Args().First(), "%"))
+	if err != nil || i < 0 || i > 100 {
+		return fmt.Errorf("volume must be a percent from 0 to 100, not %s", c.Args().First())
+	}
+	over, err := parseDuration(c.String("over"))
+	if err != nil {
+		return err
+	}
+	d := getActiveDevice(ctx)
+	if d == nil {
+		return errors.New("no devices active, please begin playback")
+	}
+	cfg := loadConfig()
+	if m, ok := cfg.MaxVolumes[d.ID]; ok && i > m {
+		fmt.Printf("Volume is limited to %d%% on %s.\n", m, d.Name)
+		i = m
+	}
+	err = fadeVolume(ctx, d.Volume, i, over, ctx.Done())
+	if err == errStopped {
+		panic(errInterrupted)
+	}
+	checkErr(err)
+	cfg.Volumes[d.ID] = i
+	saveConfig(cfg)
+	err = waitForVolume(ctx, i)
+	if err != nil {
+		return err
+	}
+	displayVolume(ctx)
+	return nil
+}
+
+// volMaxAction is called with spotcon> vol max
+// Sets the highest volume allowed on a device, or shows it if no PERCENT
+// is given. Uses the active device unless --device is set
+func volMaxAction(c *cli.Context) error {
+	ctx := getContext(c)
+	if c.NArg() > 1 {
+		return showUsage(c)
+	}
+	cfg := loadConfig()
+	d := getActiveDevice(ctx)
+	if c.IsSet("device") {
+		var err error
+		d, err = findDevice(getDevices(ctx), c.String("device"), cfg)
+		if err != nil {
+			return err
+		}
+	}
+	if d == nil {
+		return errors.New("no devices active, use --device to choose one")
+	}
+	switch {
+	case c.Bool("clear"):
+		delete(cfg.MaxVolumes, d.ID)
+	case c.NArg() == 1:
+		i, err := strconv.Atoi(strings.TrimSuffix(c.Args().First(), "%"))
+		if err != nil || i < 0 || i > 100 {
+			return fmt.Errorf("maximum volume must be a percent from 0 to 100, not %s", c.Args().First())
+		}
+		cfg.MaxVolumes[d.ID] = i
+	default:
+		if m, ok := cfg.MaxVolumes[d.ID]; ok {
+			fmt.Printf("Maximum volume on %s: %d%%\n", d.Name, m)
+		} else {
+			fmt.Printf("No maximum volume on %s.\n", d.Name)
+		}
+		return nil
+	}
+	saveConfig(cfg)
+	if m, ok := cfg.MaxVolumes[d.ID]; ok && d.Active && d.Volume > m {
+		setVolume(ctx, m)
+	}
+	return nil
+}
+
+// volPresetAction is called with spotcon> vol preset
+//   - NAME PERCENT saves a preset
+//   - NAME sets volume to a saved preset
+//   - no arguments lists the saved presets
+func volPresetAction(c *cli.Context) error {
+	ctx := getContext(c)
+	cfg := loadConfig()
+	name := strings.ToLower(c.Args().First())
+	if c.Bool("rm") {
+		if _, ok := cfg.Presets[name]; !ok || c.NArg() != 1 {
+			return fmt.Errorf("no volume preset named %s", name)
+		}
+		delete(cfg.Presets, name)
+		saveConfig(cfg)
+		return nil
+	}
+	switch c.NArg() {
+	case 0:
+		displayPresets(cfg)
+	case 1:
+		i, ok := cfg.Presets[name]
+		if !ok {
+			return fmt.Errorf("no volume preset named %s", name)
+		}
+		err := waitForVolume(ctx, setVolume(ctx, i))
+		if err != nil {
+			return err
+		}
+		displayVolume(ctx)
+	case 2:
+		i, err := strconv.Atoi(strings.TrimSuffix(c.Args().Get(1), "%"))
+		if err != nil || i < 0 || i > 100 {
+			return fmt.Errorf("volume must be a percent from 0 to 100, not %s", c.Args().Get(1))
+		}
+		cfg.Presets[name] = i
+		saveConfig(cfg)
+	default:
+		return showUsage(c)
+	}
+	return nil
+}
+
+// displayPresets prints each volume preset
+func displayPresets(cfg *config) {
+	if len(cfg.Presets) == 0 {
+		fmt.Println("No volume presets saved.")
+		return
+	}
+	var p []string
+	for k := range cfg.Presets {
+		p = append(p, k)
+	}
+	sort.Strings(p)
+	fmt.Println("Presets:")
+	for _, k := range p {
+		fmt.Printf("  %s: %d%%\n", k, cfg.Presets[k])
+	}
+}
+
+// restoreVolume sets the volume of device d to the volume last used on it,
+// limited to its maximum volume, and saves cfg
+func restoreVolume(ctx context.Context, d *spotify.PlayerDevice, cfg *config) {
+	i, ok := cfg.Volumes[d.ID]
+	if !ok {
+		i = d.Volume
+	}
+	if m, ok := cfg.MaxVolumes[d.ID]; ok && i > m {
+		i = m
+	}
+	if i != d.Volume {
+		client := newClient(ctx)
+		err := client.VolumeOpt(i, &spotify.PlayOptions{DeviceID: &d.ID})
+		checkErr(err)
+	}
+	cfg.Volumes[d.ID] = i
+	saveConfig(cfg)
+}